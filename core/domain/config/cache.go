@@ -0,0 +1,50 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/locator"
+)
+
+// CacheStore holds the vite.yaml of every commit read so far.
+// A commit never changes, so the repository is only reached the first time it is read.
+const CacheStore = datadir.Store("configs")
+
+// cacheFile returns the name of the file caching the given config file located by the given locator.
+// The checksum is hashed as it may contain characters that can't be used in a file name.
+func cacheFile(l *locator.Locator, file string) string {
+	sum := sha256.Sum256([]byte(l.Checksum() + file))
+
+	return hex.EncodeToString(sum[:]) + ".yaml"
+}
+
+// readConfig returns the contents of the given config file located by the given locator,
+// from the disk cache if it was read before.
+func readConfig(l *locator.Locator, file string) ([]byte, error) {
+	name := cacheFile(l, file)
+
+	f, err := CacheStore.Open(name, os.O_RDONLY, 0)
+	if err == nil {
+		defer f.Close()
+
+		return io.ReadAll(f)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	contents, err := l.Read(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CacheStore.WriteFile(name, contents, 0600); err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
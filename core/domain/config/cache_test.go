@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"gotest.tools/v3/assert"
+)
+
+func TestGet(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	// the repository was never cloned, the config can only come from the cache
+	l := &locator.Locator{
+		Provider:   "github",
+		Protocol:   "https",
+		Repository: "foo/cached",
+		Branch:     "main",
+		Commit:     "4e1aeb171526b75e0e891c924d4d2448f563cb7d",
+	}
+
+	err := CacheStore.WriteFile(cacheFile(l, locator.ViteFile), []byte("control_plane:\n  host: vite.example.com\n"), 0600)
+	assert.NilError(t, err)
+
+	conf, err := Get(l)
+	assert.NilError(t, err)
+	assert.Equal(t, conf.ControlPlane.Host, "vite.example.com")
+	assert.Equal(t, conf.Locator, l)
+}
+
+func TestCacheFile(t *testing.T) {
+	l := &locator.Locator{Repository: "foo/bar", Branch: "main", Commit: "a"}
+	other := &locator.Locator{Repository: "foo/bar", Branch: "main", Commit: "b"}
+
+	assert.Assert(t, cacheFile(l, locator.ViteFile) != cacheFile(other, locator.ViteFile))
+	assert.Assert(t, cacheFile(l, locator.ViteFile) != cacheFile(l, "vite.staging.yaml"))
+	assert.Equal(t, cacheFile(l, locator.ViteFile), cacheFile(&locator.Locator{Repository: "foo/bar", Branch: "main", Commit: "a"}, locator.ViteFile))
+}
+
+func TestGet2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	path := filepath.Join(t.TempDir(), locator.ViteFile)
+
+	err := os.WriteFile(path, []byte("control_plane:\n  host: first.example.com\n"), 0600)
+	assert.NilError(t, err)
+
+	l, err := locator.NewLocal(path, "")
+	assert.NilError(t, err)
+
+	_, err = Get(l)
+	assert.NilError(t, err)
+
+	err = os.WriteFile(path, []byte("control_plane:\n  host: second.example.com\n"), 0600)
+	assert.NilError(t, err)
+
+	// a deployment of a local config may be rolled back after the file changed
+	delete(configCache, l.Checksum())
+
+	conf, err := Get(l)
+	assert.NilError(t, err)
+	assert.Equal(t, conf.ControlPlane.Host, "first.example.com")
+}
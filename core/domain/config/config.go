@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
 	"github.com/vite-cloud/vite/core/domain/locator"
-	"gopkg.in/yaml.v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds vite's configuration.
@@ -21,6 +26,12 @@ type Config struct {
 		Host string `json:"host"`
 	} `json:"controlPlane"`
 
+	// Retention defines which deployments are kept after a successful deployment.
+	Retention Retention `json:"retention"`
+
+	// Redact holds additional patterns matching the names of env variables to redact, see DefaultRedactPatterns.
+	Redact []string `json:"redact"`
+
 	Locator *locator.Locator `json:"locator"`
 }
 
@@ -49,8 +60,288 @@ type Service struct {
 
 	// Registry is the auth configuration for the service's registry.
 	Registry *types.AuthConfig `yaml:"registry"`
+
+	// CredentialHelper is the name of the docker-credential-* program providing the credentials of the registry, if any.
+	// Without credentials nor helper, they are read from the Docker CLI config of the host, see runtime.ResolveAuth.
+	CredentialHelper string `json:"credentialHelper"`
+
+	// Healthcheck overrides the healthcheck of the service's image, if any.
+	Healthcheck *Healthcheck `json:"healthcheck"`
+
+	// Resources limits the resources available to the service's container.
+	Resources Resources `json:"resources"`
+
+	// Restart is the restart policy of the service's container, empty means always.
+	Restart container.RestartPolicy `json:"restart"`
+
+	// Volumes are mounted in the service's container.
+	Volumes []Volume `json:"volumes"`
+
+	// Replicas is the number of containers running the service, it is at least 1.
+	Replicas int `json:"replicas"`
+
+	// LoadBalancing is how the proxy spreads requests across the replicas of the service.
+	LoadBalancing LoadBalancing `json:"loadBalancing"`
+}
+
+// Resources limits the resources available to a service's container.
+// Zero values mean no limit.
+type Resources struct {
+	// Memory is the maximum amount of memory the container may use.
+	Memory MemorySize `json:"memory" yaml:"memory"`
+	// MemoryReservation is a soft limit enforced when the host runs low on memory.
+	MemoryReservation MemorySize `json:"memoryReservation" yaml:"memory_reservation"`
+	// CPUShares is the relative weight of the container when CPU time is contended.
+	CPUShares int64 `json:"cpuShares" yaml:"cpu_shares"`
+	// CPUQuota is the CPU time in microseconds the container may use during each CPUPeriod.
+	CPUQuota int64 `json:"cpuQuota" yaml:"cpu_quota"`
+	// CPUPeriod is the length of a CPU period in microseconds, Docker defaults to 100ms.
+	CPUPeriod int64 `json:"cpuPeriod" yaml:"cpu_period"`
+	// PidsLimit is the maximum number of processes in the container.
+	PidsLimit int64 `json:"pidsLimit" yaml:"pids_limit"`
 }
 
+// MemorySize is an amount of memory in bytes.
+// In YAML, it may be written as a number of bytes or using a unit such as 512m or 1g.
+type MemorySize int64
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *MemorySize) UnmarshalYAML(unmarshal func(any) error) error {
+	var raw string
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	size, err := units.RAMInBytes(raw)
+	if err != nil {
+		return err
+	}
+
+	*m = MemorySize(size)
+
+	return nil
+}
+
+// ErrInvalidResources is returned when resources are misconfigured.
+var ErrInvalidResources = errors.New("invalid resources")
+
+// Validate returns an error if the resources are misconfigured.
+func (r Resources) Validate() error {
+	if r.Memory < 0 || r.MemoryReservation < 0 || r.CPUShares < 0 || r.CPUQuota < 0 || r.CPUPeriod < 0 || r.PidsLimit < 0 {
+		return fmt.Errorf("%w: limits can not be negative", ErrInvalidResources)
+	}
+
+	if r.Memory > 0 && r.MemoryReservation > r.Memory {
+		return fmt.Errorf("%w: memory_reservation must be lower than memory", ErrInvalidResources)
+	}
+
+	return nil
+}
+
+// DockerResources returns the Docker resources matching the resources.
+func (r Resources) DockerResources() container.Resources {
+	resources := container.Resources{
+		Memory:            int64(r.Memory),
+		MemoryReservation: int64(r.MemoryReservation),
+		CPUShares:         r.CPUShares,
+		CPUQuota:          r.CPUQuota,
+		CPUPeriod:         r.CPUPeriod,
+	}
+
+	if r.PidsLimit > 0 {
+		resources.PidsLimit = &r.PidsLimit
+	}
+
+	return resources
+}
+
+// ErrInvalidRestartPolicy is returned when a restart policy can not be parsed.
+var ErrInvalidRestartPolicy = errors.New("invalid restart policy, expected one of no, always, unless-stopped, on-failure[:max-retries]")
+
+// ParseRestartPolicy parses a restart policy written as Docker does: no, always,
+// unless-stopped or on-failure[:max-retries]. An empty policy is left empty,
+// the runtime then restarts the container always.
+func ParseRestartPolicy(policy string) (container.RestartPolicy, error) {
+	name, retries, hasRetries := strings.Cut(policy, ":")
+
+	switch name {
+	case "":
+		return container.RestartPolicy{}, nil
+	case "no", "always", "unless-stopped":
+		if hasRetries {
+			return container.RestartPolicy{}, fmt.Errorf("%w: %s", ErrInvalidRestartPolicy, policy)
+		}
+
+		return container.RestartPolicy{Name: name}, nil
+	case "on-failure":
+		if !hasRetries {
+			return container.RestartPolicy{Name: name}, nil
+		}
+
+		count, err := strconv.Atoi(retries)
+		if err != nil || count < 0 {
+			return container.RestartPolicy{}, fmt.Errorf("%w: %s", ErrInvalidRestartPolicy, policy)
+		}
+
+		return container.RestartPolicy{Name: name, MaximumRetryCount: count}, nil
+	}
+
+	return container.RestartPolicy{}, fmt.Errorf("%w: %s", ErrInvalidRestartPolicy, policy)
+}
+
+// ErrInvalidCredentialHelper is returned when the name of a credential helper is not a valid program suffix.
+var ErrInvalidCredentialHelper = errors.New("invalid credential helper, expected letters, digits, _, . or -")
+
+// credentialHelperRegex matches the names of credential helpers, run as docker-credential-<name>.
+var credentialHelperRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// ValidateCredentialHelper returns an error if the given credential helper can not be run as docker-credential-<helper>.
+func ValidateCredentialHelper(helper string) error {
+	if !credentialHelperRegex.MatchString(helper) || strings.Contains(helper, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidCredentialHelper, helper)
+	}
+
+	return nil
+}
+
+// ErrInvalidReplicas is returned when the number of replicas of a service is negative.
+var ErrInvalidReplicas = errors.New("invalid replicas, expected a positive number")
+
+// LoadBalancing is a strategy to spread requests across the replicas of a service.
+type LoadBalancing string
+
+// Available load balancing strategies.
+const (
+	// RoundRobin sends requests to each replica in turn.
+	RoundRobin LoadBalancing = "round_robin"
+	// LeastConnections sends requests to the replica serving the fewest requests.
+	LeastConnections LoadBalancing = "least_connections"
+)
+
+// ErrInvalidLoadBalancing is returned when a load balancing strategy can not be parsed.
+var ErrInvalidLoadBalancing = errors.New("invalid load balancing, expected one of round_robin, least_connections")
+
+// ParseLoadBalancing parses a load balancing strategy, an empty strategy defaults to RoundRobin.
+func ParseLoadBalancing(strategy string) (LoadBalancing, error) {
+	switch LoadBalancing(strategy) {
+	case "":
+		return RoundRobin, nil
+	case RoundRobin, LeastConnections:
+		return LoadBalancing(strategy), nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidLoadBalancing, strategy)
+}
+
+// Healthcheck defines how to check that a service is healthy.
+// Exactly one of HTTP, TCP or Command must be set.
+type Healthcheck struct {
+	// HTTP checks that a request to the given path and port succeeds.
+	HTTP *HTTPCheck `json:"http" yaml:"http"`
+	// TCP checks that a connection to the given port can be opened.
+	TCP *TCPCheck `json:"tcp" yaml:"tcp"`
+	// Command is a shell command that must exit with a zero status.
+	Command string `json:"command" yaml:"command"`
+
+	// Interval is the time between two checks.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Timeout is the time after which a check is considered to have failed.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// Retries is the number of consecutive failures needed to consider the service unhealthy.
+	Retries int `json:"retries" yaml:"retries"`
+	// StartPeriod is the time given to the service to start before failures are counted.
+	StartPeriod time.Duration `json:"startPeriod" yaml:"start_period"`
+}
+
+// HTTPCheck is an HTTP healthcheck.
+type HTTPCheck struct {
+	Path string `json:"path" yaml:"path"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+// TCPCheck is a TCP healthcheck.
+type TCPCheck struct {
+	Port int `json:"port" yaml:"port"`
+}
+
+// ErrInvalidHealthcheck is returned when a healthcheck is misconfigured.
+var ErrInvalidHealthcheck = errors.New("invalid healthcheck")
+
+// Validate returns an error if the healthcheck is misconfigured.
+func (h *Healthcheck) Validate() error {
+	kinds := 0
+
+	if h.HTTP != nil {
+		kinds++
+
+		if h.HTTP.Port <= 0 {
+			return fmt.Errorf("%w: http.port must be set", ErrInvalidHealthcheck)
+		}
+	}
+
+	if h.TCP != nil {
+		kinds++
+
+		if h.TCP.Port <= 0 {
+			return fmt.Errorf("%w: tcp.port must be set", ErrInvalidHealthcheck)
+		}
+	}
+
+	if h.Command != "" {
+		kinds++
+	}
+
+	if kinds != 1 {
+		return fmt.Errorf("%w: exactly one of http, tcp or command must be set", ErrInvalidHealthcheck)
+	}
+
+	if h.Interval < 0 || h.Timeout < 0 || h.Retries < 0 || h.StartPeriod < 0 {
+		return fmt.Errorf("%w: interval, timeout, retries and start_period can not be negative", ErrInvalidHealthcheck)
+	}
+
+	return nil
+}
+
+// HealthConfig returns the Docker healthcheck matching the healthcheck.
+// Images do not necessarily ship with curl or nc, so checks fall back to other tools.
+func (h *Healthcheck) HealthConfig() *container.HealthConfig {
+	var command string
+
+	switch {
+	case h.HTTP != nil:
+		url := fmt.Sprintf("http://127.0.0.1:%d/%s", h.HTTP.Port, strings.TrimPrefix(h.HTTP.Path, "/"))
+		command = fmt.Sprintf("curl -fsS -o /dev/null %[1]s || wget -q -O /dev/null %[1]s || exit 1", url)
+	case h.TCP != nil:
+		command = fmt.Sprintf("nc -z 127.0.0.1 %[1]d || bash -c 'echo > /dev/tcp/127.0.0.1/%[1]d' || exit 1", h.TCP.Port)
+	default:
+		command = h.Command
+	}
+
+	return &container.HealthConfig{
+		Test:        []string{"CMD-SHELL", command},
+		Interval:    h.Interval,
+		Timeout:     h.Timeout,
+		Retries:     h.Retries,
+		StartPeriod: h.StartPeriod,
+	}
+}
+
+// Retention defines which deployments are kept after a successful deployment.
+// A deployment is kept if it is one of the Keep most recent successful deployments or
+// if it is newer than MaxAge. Nothing is removed if neither is set.
+type Retention struct {
+	// Keep is the number of most recent successful deployments to keep.
+	Keep int `json:"keep" yaml:"keep"`
+	// MaxAge is the age after which deployments may be removed.
+	MaxAge time.Duration `json:"maxAge" yaml:"max_age"`
+}
+
+// IsEnabled returns true if the retention policy may remove deployments.
+func (r Retention) IsEnabled() bool {
+	return r.Keep > 0 || r.MaxAge > 0
+}
+
+// configCache holds the configs parsed by this process, it is backed by CacheStore.
 var configCache = make(map[string]*Config)
 
 // GetUsingDefaultLocator returns the Config given a config locator.Locator.
@@ -68,27 +359,101 @@ func Get(l *locator.Locator) (*Config, error) {
 		return configCache[l.Checksum()], nil
 	}
 
-	contents, err := l.Read("vite.yaml")
-	if errors.Is(err, locator.ErrInvalidCommit) {
-		return nil, fmt.Errorf("could not read the config, no commit specified: run `vite use` to pick one")
-	} else if err != nil {
+	converted, err := read(l, readConfig)
+	if err != nil {
 		return nil, err
 	}
 
-	var c configYAML
-	err = yaml.Unmarshal(contents, &c)
-	if err != nil {
-		return nil, err
+	configCache[l.Checksum()] = converted
+
+	return converted, nil
+}
+
+// Parse reads and validates the config located by the given locator, bypassing the caches.
+func Parse(l *locator.Locator) (*Config, error) {
+	return read(l, func(l *locator.Locator, file string) ([]byte, error) {
+		return l.Read(file)
+	})
+}
+
+// read reads the config files of the given locator using readFile and parses them.
+func read(l *locator.Locator, readFile func(*locator.Locator, string) ([]byte, error)) (*Config, error) {
+	var files []configFile
+
+	for _, name := range l.ConfigFiles() {
+		contents, err := readFile(l, name)
+		if errors.Is(err, locator.ErrInvalidCommit) {
+			return nil, fmt.Errorf("could not read the config, no commit specified: run `vite use` to pick one")
+		} else if err != nil {
+			return nil, err
+		}
+
+		files = append(files, configFile{Name: name, Contents: contents})
 	}
 
-	converted, err := c.ToConfig()
+	converted, err := parseFiles(files)
 	if err != nil {
 		return nil, err
 	}
 
 	converted.Locator = l
 
-	configCache[l.Checksum()] = converted
-
 	return converted, nil
 }
+
+// Expand returns a copy of the config in which the env variables and registry credentials
+// of services have been passed through expand. The config itself is left untouched
+// as it is cached and may be served as is.
+func (c *Config) Expand(expand func(string) (string, error)) (*Config, error) {
+	return c.transform(expand, expand)
+}
+
+// transform returns a copy of the config in which env variables have been passed through env
+// and registry credentials through credential.
+func (c *Config) transform(env, credential func(string) (string, error)) (*Config, error) {
+	transformed := *c
+	transformed.Services = make(map[string]*Service, len(c.Services))
+
+	for name, service := range c.Services {
+		copied := *service
+		copied.Env = nil
+
+		for _, variable := range service.Env {
+			value, err := env(variable)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+
+			copied.Env = append(copied.Env, value)
+		}
+
+		if service.Registry != nil {
+			registry := *service.Registry
+
+			for _, field := range []*string{&registry.Username, &registry.Password, &registry.Auth, &registry.IdentityToken, &registry.RegistryToken} {
+				value, err := credential(*field)
+				if err != nil {
+					return nil, fmt.Errorf("service %s: registry: %w", name, err)
+				}
+
+				*field = value
+			}
+
+			copied.Registry = &registry
+		}
+
+		transformed.Services[name] = &copied
+	}
+
+	// Dependencies must point to the copies.
+	for _, service := range transformed.Services {
+		var requires []*Service
+		for _, require := range service.Requires {
+			requires = append(requires, transformed.Services[require.Name])
+		}
+
+		service.Requires = requires
+	}
+
+	return &transformed, nil
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"gotest.tools/v3/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthcheck_Validate(t *testing.T) {
+	tests := []struct {
+		healthcheck Healthcheck
+		err         string
+	}{
+		{Healthcheck{HTTP: &HTTPCheck{Path: "/", Port: 80}}, ""},
+		{Healthcheck{TCP: &TCPCheck{Port: 5432}}, ""},
+		{Healthcheck{Command: "pg_isready"}, ""},
+		{Healthcheck{}, "exactly one of http, tcp or command must be set"},
+		{Healthcheck{HTTP: &HTTPCheck{Path: "/"}}, "http.port must be set"},
+		{Healthcheck{TCP: &TCPCheck{}}, "tcp.port must be set"},
+		{Healthcheck{Command: "true", Retries: -1}, "can not be negative"},
+	}
+
+	for _, test := range tests {
+		err := test.healthcheck.Validate()
+		if test.err == "" {
+			assert.NilError(t, err)
+			continue
+		}
+
+		assert.ErrorIs(t, err, ErrInvalidHealthcheck)
+		assert.ErrorContains(t, err, test.err)
+	}
+}
+
+func TestHealthcheck_HealthConfig(t *testing.T) {
+	healthcheck := &Healthcheck{
+		HTTP:        &HTTPCheck{Path: "/health", Port: 8080},
+		Interval:    5 * time.Second,
+		Timeout:     time.Second,
+		Retries:     3,
+		StartPeriod: 10 * time.Second,
+	}
+
+	got := healthcheck.HealthConfig()
+	assert.DeepEqual(t, got.Test, []string{"CMD-SHELL", "curl -fsS -o /dev/null http://127.0.0.1:8080/health || wget -q -O /dev/null http://127.0.0.1:8080/health || exit 1"})
+	assert.Equal(t, got.Interval, 5*time.Second)
+	assert.Equal(t, got.Timeout, time.Second)
+	assert.Equal(t, got.Retries, 3)
+	assert.Equal(t, got.StartPeriod, 10*time.Second)
+
+	got = (&Healthcheck{Command: "pg_isready"}).HealthConfig()
+	assert.DeepEqual(t, got.Test, []string{"CMD-SHELL", "pg_isready"})
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   container.RestartPolicy
+		err    bool
+	}{
+		{"", container.RestartPolicy{}, false},
+		{"no", container.RestartPolicy{Name: "no"}, false},
+		{"always", container.RestartPolicy{Name: "always"}, false},
+		{"unless-stopped", container.RestartPolicy{Name: "unless-stopped"}, false},
+		{"on-failure", container.RestartPolicy{Name: "on-failure"}, false},
+		{"on-failure:5", container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 5}, false},
+		{"on-failure:-1", container.RestartPolicy{}, true},
+		{"on-failure:many", container.RestartPolicy{}, true},
+		{"always:3", container.RestartPolicy{}, true},
+		{"sometimes", container.RestartPolicy{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRestartPolicy(test.policy)
+		if test.err {
+			assert.ErrorIs(t, err, ErrInvalidRestartPolicy)
+			continue
+		}
+
+		assert.NilError(t, err)
+		assert.Equal(t, got, test.want)
+	}
+}
+
+func TestResources_Validate(t *testing.T) {
+	assert.NilError(t, Resources{}.Validate())
+	assert.NilError(t, Resources{Memory: 512, MemoryReservation: 256}.Validate())
+	assert.NilError(t, Resources{MemoryReservation: 256}.Validate())
+	assert.ErrorIs(t, Resources{Memory: 256, MemoryReservation: 512}.Validate(), ErrInvalidResources)
+	assert.ErrorIs(t, Resources{CPUShares: -1}.Validate(), ErrInvalidResources)
+}
+
+func TestResources_DockerResources(t *testing.T) {
+	resources := Resources{
+		Memory:    512,
+		CPUShares: 512,
+		CPUQuota:  50000,
+		PidsLimit: 100,
+	}.DockerResources()
+
+	assert.Equal(t, resources.Memory, int64(512))
+	assert.Equal(t, resources.CPUShares, int64(512))
+	assert.Equal(t, resources.CPUQuota, int64(50000))
+	assert.Equal(t, *resources.PidsLimit, int64(100))
+
+	assert.Assert(t, Resources{}.DockerResources().PidsLimit == nil)
+}
+
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Volume
+		err  string
+	}{
+		{"pgdata:/var/lib/postgresql/data", Volume{Source: "pgdata", Target: "/var/lib/postgresql/data"}, ""},
+		{"/etc/ssl:/etc/ssl:ro", Volume{Source: "/etc/ssl", Target: "/etc/ssl", ReadOnly: true}, ""},
+		{"cache:/cache:rw", Volume{Source: "cache", Target: "/cache"}, ""},
+		{"pgdata", Volume{}, "expected"},
+		{"pgdata:data", Volume{}, "must be an absolute path"},
+		{"./data:/data", Volume{}, "must be a volume name or an absolute path"},
+		{"pgdata:/data:rx", Volume{}, "unknown mode rx"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseVolume(test.spec)
+		if test.err != "" {
+			assert.ErrorIs(t, err, ErrInvalidVolume)
+			assert.ErrorContains(t, err, test.err)
+			continue
+		}
+
+		assert.NilError(t, err)
+		assert.Equal(t, got, test.want)
+	}
+
+	assert.Assert(t, Volume{Source: "/data"}.IsBind())
+	assert.Assert(t, !Volume{Source: "data"}.IsBind())
+}
+
+func TestConfig_Expand(t *testing.T) {
+	db := &Service{Name: "db", Env: []string{"PASSWORD=${secret:DB}"}}
+	api := &Service{
+		Name:     "api",
+		Env:      []string{"DB_PASSWORD=${secret:DB}"},
+		Requires: []*Service{db},
+		Registry: &types.AuthConfig{Username: "me", Password: "${secret:REGISTRY}"},
+	}
+	conf := &Config{Services: map[string]*Service{"db": db, "api": api}}
+
+	expanded, err := conf.Expand(func(s string) (string, error) {
+		return strings.NewReplacer("${secret:DB}", "hunter2", "${secret:REGISTRY}", "pass").Replace(s), nil
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, expanded.Services["api"].Env, []string{"DB_PASSWORD=hunter2"})
+	assert.Equal(t, expanded.Services["api"].Registry.Password, "pass")
+	assert.Equal(t, expanded.Services["api"].Registry.Username, "me")
+	assert.Equal(t, expanded.Services["api"].Requires[0], expanded.Services["db"])
+	assert.DeepEqual(t, expanded.Services["db"].Env, []string{"PASSWORD=hunter2"})
+
+	// the original config is left untouched
+	assert.DeepEqual(t, api.Env, []string{"DB_PASSWORD=${secret:DB}"})
+	assert.Equal(t, api.Registry.Password, "${secret:REGISTRY}")
+	assert.Equal(t, api.Requires[0], db)
+
+	_, err = conf.Expand(func(s string) (string, error) {
+		return "", errors.New("boom")
+	})
+	assert.ErrorContains(t, err, "boom")
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LineError is an error located in a config file.
+type LineError struct {
+	File string
+	// Line starts at 1, it is 0 if the error is not located at a specific line.
+	Line int
+	// Column starts at 1, it is 0 if the column is unknown.
+	Column int
+	Err    error
+}
+
+func (e *LineError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %s", e.File, e.Err)
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Err)
+	}
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList holds every error found in a config file, one per line.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Is returns true if any of the errors matches the target.
+func (l ErrorList) Is(target error) bool {
+	for _, err := range l {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnsetVariable is returned when interpolating a variable that is not set and has no default.
+var ErrUnsetVariable = errors.New("variable is not set")
+
+// variableRegex matches $$, ${VAR} and ${VAR:-default}.
+// References to secrets, such as ${secret:DB_PASSWORD}, are left as is.
+var variableRegex = regexp.MustCompile(`\$\$(\{secret:)?|\$\{([a-zA-Z_][a-zA-Z0-9_]*)(?::-([^}]*))?}`)
+
+// interpolate replaces ${VAR} and ${VAR:-default} in the values decoded from the given file with the value
+// of the variable returned by lookup, or with the default if the variable is not set or empty.
+// $$ is replaced with $, except before a secret reference: $${secret:NAME} is left for the secrets to unescape.
+// Values are interpolated once decoded so that variables can't change the structure of the file.
+func interpolate(file configFile, value interface{}, lookup func(string) (string, bool)) (interface{}, error) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		for key, item := range value {
+			interpolated, err := interpolate(file, item, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			value[key] = interpolated
+		}
+	case []interface{}:
+		for i, item := range value {
+			interpolated, err := interpolate(file, item, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			value[i] = interpolated
+		}
+	case string:
+		return interpolateString(file, value, lookup)
+	}
+
+	return value, nil
+}
+
+// interpolateString interpolates a string, the result is resolved to a number or a boolean if it is one
+// so that variables can be used for keys such as replicas.
+func interpolateString(file configFile, s string, lookup func(string) (string, bool)) (interface{}, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var err error
+
+	interpolated := variableRegex.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableRegex.FindStringSubmatch(match)
+
+		switch {
+		case groups[1] != "":
+			return match
+		case match == "$$":
+			return "$"
+		}
+
+		name, hasDefault := groups[2], strings.Contains(match, ":-")
+
+		value, ok := lookup(name)
+		if ok && value != "" {
+			return value
+		}
+
+		if hasDefault {
+			return groups[3]
+		}
+
+		if err == nil {
+			err = &LineError{File: file.Name, Line: locateVariable(file.Contents, name), Err: fmt.Errorf("%w: %s (set it or use ${%s:-default})", ErrUnsetVariable, name, name)}
+		}
+
+		return ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveScalar(interpolated), nil
+}
+
+// resolveScalar returns the number or boolean written as s, or s itself.
+// Only values written back the same way are resolved, so that 1.20 is not turned into 1.2.
+func resolveScalar(s string) interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		return s
+	}
+
+	switch value.(type) {
+	case int, int64, uint64, float64, bool:
+		if encoded, err := yaml.Marshal(value); err == nil && strings.TrimSpace(string(encoded)) == s {
+			return value
+		}
+	}
+
+	return s
+}
+
+// locateVariable returns the first line using the given variable outside a comment, or 0 if there is none.
+func locateVariable(contents []byte, name string) int {
+	for n, line := range strings.Split(string(contents), "\n") {
+		if i := strings.Index(line, "#"); i == 0 || (i > 0 && strings.ContainsAny(line[i-1:i], " \t")) {
+			line = line[:i]
+		}
+
+		if strings.Contains(line, "${"+name+"}") || strings.Contains(line, "${"+name+":-") {
+			return n + 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	lookup := lookupFrom(map[string]string{"TAG": "1.2.0", "EMPTY": "", "REPLICAS": "3", "VERSION": "1.20", "MESSAGE": "a: b # c\n'd'"})
+
+	tests := []struct {
+		in   string
+		want interface{}
+	}{
+		{"app:${TAG}", "app:1.2.0"},
+		{"app:${MISSING:-latest}", "app:latest"},
+		{"app:${EMPTY:-latest}", "app:latest"},
+		{"A=${MISSING:-}", "A="},
+		{"PRICE=$$5", "PRICE=$5"},
+		{"LITERAL=$${TAG}", "LITERAL=${TAG}"},
+		// secrets are resolved when deploying
+		{"DB=${secret:DB_PASSWORD}", "DB=${secret:DB_PASSWORD}"},
+		{"LITERAL=$${secret:DB_PASSWORD}", "LITERAL=$${secret:DB_PASSWORD}"},
+		// values are not parsed as YAML
+		{"MESSAGE=${MESSAGE}", "MESSAGE=a: b # c\n'd'"},
+		{"${MESSAGE}", "a: b # c\n'd'"},
+		{"${REPLICAS}", 3},
+		{"${VERSION}", "1.20"},
+	}
+
+	for _, test := range tests {
+		got, err := interpolate(configFile{Name: "vite.yaml"}, test.in, lookup)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, test.want)
+	}
+}
+
+func TestInterpolate2(t *testing.T) {
+	file := configFile{Name: "vite.yaml", Contents: []byte("services:\n  # image: app:${TAG}\n  app:\n    image: app # ${TAG}\n    env:\n      - TAG=${TAG}\n")}
+
+	var values map[interface{}]interface{}
+	assert.NilError(t, yaml.Unmarshal(file.Contents, &values))
+
+	_, err := interpolate(file, values, lookupFrom(nil))
+	assert.ErrorIs(t, err, ErrUnsetVariable)
+	assert.ErrorContains(t, err, "vite.yaml:6: variable is not set: TAG")
+}
+
+func TestInterpolate3(t *testing.T) {
+	file := configFile{Name: "vite.yaml", Contents: []byte("services:\n  app:\n    image: app # ${UNSET}\n    replicas: ${REPLICAS}\n")}
+
+	var values map[interface{}]interface{}
+	assert.NilError(t, yaml.Unmarshal(file.Contents, &values))
+
+	got, err := interpolate(file, values, lookupFrom(map[string]string{"REPLICAS": "2"}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, map[interface{}]interface{}{
+		"services": map[interface{}]interface{}{
+			"app": map[interface{}]interface{}{"image": "app", "replicas": 2},
+		},
+	})
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configFile is a file the config is read from.
+type configFile struct {
+	Name     string
+	Contents []byte
+}
+
+// yamlLineRegex matches the line number in the errors of the yaml package.
+var yamlLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// parseFiles interpolates the variables of each file, merges them in order and converts the result to a Config.
+// Errors are located at the file, and line if possible, they come from.
+func parseFiles(files []configFile) (*Config, error) {
+	var c configYAML
+	merged := map[interface{}]interface{}{}
+	interpolated := false
+
+	for _, file := range files {
+		var overlay map[interface{}]interface{}
+		if err := yaml.Unmarshal(file.Contents, &overlay); err != nil {
+			return nil, yamlError(file, err)
+		}
+
+		// files without variables are decoded as is so that errors point to their exact line
+		var contents []byte
+		if bytes.Contains(file.Contents, []byte("$")) {
+			values, err := interpolate(file, overlay, os.LookupEnv)
+			if err != nil {
+				return nil, err
+			}
+
+			overlay, _ = values.(map[interface{}]interface{})
+			if contents, err = yaml.Marshal(overlay); err != nil {
+				return nil, err
+			}
+
+			interpolated = true
+		}
+
+		// Each file is decoded on its own first so that errors are located in the right file.
+		if err := decodeStrict(file, contents); err != nil {
+			return nil, err
+		}
+
+		merged = merge(merged, overlay)
+	}
+
+	// a single file is decoded as is, without going through the merged map
+	contents := files[0].Contents
+	if len(files) > 1 || interpolated {
+		var err error
+		if contents, err = yaml.Marshal(merged); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := yaml.Unmarshal(contents, &c); err != nil {
+		return nil, yamlError(files[len(files)-1], err)
+	}
+
+	conf, err := c.ToConfig()
+
+	var serviceErr *ServiceError
+	if errors.As(err, &serviceErr) {
+		return nil, locateService(files, serviceErr)
+	} else if err != nil {
+		return nil, &LineError{File: files[len(files)-1].Name, Err: err}
+	}
+
+	return conf, nil
+}
+
+// merge merges overlay over base: maps are merged recursively and any other value is replaced.
+func merge(base, overlay map[interface{}]interface{}) map[interface{}]interface{} {
+	for key, value := range overlay {
+		baseMap, baseIsMap := base[key].(map[interface{}]interface{})
+		overlayMap, overlayIsMap := value.(map[interface{}]interface{})
+
+		if baseIsMap && overlayIsMap {
+			base[key] = merge(baseMap, overlayMap)
+		} else {
+			base[key] = value
+		}
+	}
+
+	return base
+}
+
+// yamlError locates the errors of the yaml package in the given file.
+func yamlError(file configFile, err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		var errs ErrorList
+		for _, message := range typeErr.Errors {
+			errs = append(errs, locateMessage(file, message))
+		}
+
+		return errs
+	}
+
+	return locateMessage(file, err.Error())
+}
+
+// locateMessage converts an error message of the yaml package to a LineError.
+func locateMessage(file configFile, message string) *LineError {
+	err := &LineError{File: file.Name}
+
+	message = strings.TrimPrefix(message, "yaml: ")
+	if match := yamlLineRegex.FindStringSubmatch(message); match != nil {
+		err.Line, _ = strconv.Atoi(match[1])
+		message = match[2]
+	}
+
+	if match := unknownFieldRegex.FindStringSubmatch(message); match != nil {
+		err.Line, err.Column = locateKey(file.Contents, err.Line, match[1])
+		err.Err = unknownKeyError(match[1], match[2])
+
+		return err
+	}
+
+	err.Err = errors.New(message)
+
+	return err
+}
+
+// locateKey returns the line and column of the given key.
+// If the line is unknown, the first line declaring the key is used.
+func locateKey(contents []byte, line int, key string) (int, int) {
+	for n, text := range strings.Split(string(contents), "\n") {
+		if line != 0 && n+1 != line {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(text, " \t-")
+		if line == 0 && !strings.HasPrefix(trimmed, key+":") {
+			continue
+		}
+
+		if column := strings.Index(text, key); column >= 0 {
+			return n + 1, column + 1
+		}
+	}
+
+	return line, 0
+}
+
+// locateService locates an error in the last file declaring the service.
+func locateService(files []configFile, err *ServiceError) error {
+	name := regexp.MustCompile(`^["']?` + regexp.QuoteMeta(err.Service) + `["']?\s*:`)
+
+	for i := len(files) - 1; i >= 0; i-- {
+		inServices := false
+		indent := ""
+
+		for n, line := range strings.Split(string(files[i].Contents), "\n") {
+			trimmed := strings.TrimLeft(line, " \t")
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+
+			if trimmed == line {
+				inServices = strings.HasPrefix(line, "services:")
+				indent = ""
+				continue
+			}
+
+			if !inServices {
+				continue
+			}
+
+			// services are declared at the indentation of the first one
+			if indent == "" {
+				indent = line[:len(line)-len(trimmed)]
+			}
+
+			if line[:len(line)-len(trimmed)] == indent && name.MatchString(trimmed) {
+				return &LineError{File: files[i].Name, Line: n + 1, Err: err}
+			}
+		}
+	}
+
+	return &LineError{File: files[len(files)-1].Name, Err: err}
+}
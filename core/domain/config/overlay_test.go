@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("APP_TAG", "1.2.0")
+
+	conf, err := parseFiles([]configFile{
+		{Name: "vite.yaml", Contents: []byte(`
+services:
+  app:
+    image: app:${APP_TAG}
+    hosts: [staging.example.com]
+    env: [MODE=production]
+  worker:
+    image: worker:${WORKER_TAG:-latest}
+control_plane:
+  host: vite.example.com
+`)},
+		{Name: "vite.staging.yaml", Contents: []byte(`
+services:
+  app:
+    hosts: [staging.example.com]
+    env: [MODE=staging]
+`)},
+	})
+	assert.NilError(t, err)
+
+	assert.Equal(t, conf.Services["app"].Image, "app:1.2.0")
+	assert.DeepEqual(t, conf.Services["app"].Hosts, []string{"staging.example.com"})
+	assert.DeepEqual(t, conf.Services["app"].Env, []string{"MODE=staging"})
+	assert.Equal(t, conf.Services["worker"].Image, "worker:latest")
+	assert.Equal(t, conf.ControlPlane.Host, "vite.example.com")
+}
+
+func TestParse3(t *testing.T) {
+	t.Setenv("GREETING", "hello: world # 'quoted'")
+	t.Setenv("REPLICAS", "2")
+
+	conf, err := parseFiles([]configFile{{Name: "vite.yaml", Contents: []byte(`
+services:
+  app:
+    image: app # ${UNSET_VARIABLE}
+    env:
+      - GREETING=${GREETING}
+    replicas: ${REPLICAS}
+`)}})
+	assert.NilError(t, err)
+
+	assert.Equal(t, conf.Services["app"].Image, "app")
+	assert.DeepEqual(t, conf.Services["app"].Env, []string{"GREETING=hello: world # 'quoted'"})
+	assert.Equal(t, conf.Services["app"].Replicas, 2)
+}
+
+func TestParse2(t *testing.T) {
+	base := configFile{Name: "vite.yaml", Contents: []byte("services:\n  app:\n    image: app\n  web:\n    image: web\n")}
+
+	tests := []struct {
+		overlay string
+		err     string
+	}{
+		{"services:\n  web:\n    restart: sometimes\n", "vite.staging.yaml:2: service web: invalid restart policy"},
+		{"services:\n  web:\n    requires: [db]\n", "vite.staging.yaml:2: service web: requires service db which does not exist"},
+		{"services:\n  web:\n    hosts: example.com\n", "vite.staging.yaml:3: cannot unmarshal"},
+		{"services:\n  web:\n\timage: web\n", "vite.staging.yaml:3: found character that cannot start any token"},
+		{"services:\n  web:\n    image: ${UNSET_VARIABLE}\n", "vite.staging.yaml:3: variable is not set: UNSET_VARIABLE"},
+		// only services are matched, not their keys
+		{"services:\n  app:\n    image: app\n  web:\n    image: web\n  db:\n    requires: [cache]\n", "vite.staging.yaml:6: service db"},
+	}
+
+	for _, test := range tests {
+		_, err := parseFiles([]configFile{base, {Name: "vite.staging.yaml", Contents: []byte(test.overlay)}})
+		assert.ErrorContains(t, err, test.err)
+	}
+
+	// services only declared in the base are located in it
+	_, err := parseFiles([]configFile{{Name: "vite.yaml", Contents: []byte("services:\n  app:\n    image: app\n    restart: sometimes\n")}, {Name: "vite.staging.yaml", Contents: []byte("proxy:\n  http: 8080\n")}})
+	assert.ErrorContains(t, err, "vite.yaml:2: service app: invalid restart policy")
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values.
+const Redacted = "[redacted]"
+
+// DefaultRedactPatterns match the names of env variables whose value is always redacted.
+// Patterns are matched case-insensitively using path.Match.
+var DefaultRedactPatterns = []string{"*PASSWORD*", "*PASSWD*", "*SECRET*", "*TOKEN*", "*KEY*", "*CREDENTIAL*", "*AUTH*"}
+
+// Redactor masks sensitive values before they are displayed or logged.
+type Redactor struct {
+	patterns []string
+}
+
+// NewRedactor returns a Redactor matching DefaultRedactPatterns and the given patterns.
+func NewRedactor(patterns ...string) Redactor {
+	all := append([]string{}, DefaultRedactPatterns...)
+
+	for _, pattern := range patterns {
+		all = append(all, strings.ToUpper(pattern))
+	}
+
+	return Redactor{patterns: all}
+}
+
+// IsSensitive returns true if the value of the env variable with the given name must be redacted.
+func (r Redactor) IsSensitive(name string) bool {
+	name = strings.ToUpper(name)
+
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Env redacts the value of an env variable written as NAME=value if its name is sensitive.
+func (r Redactor) Env(env string) string {
+	name, _, found := strings.Cut(env, "=")
+	if !found || !r.IsSensitive(name) {
+		return env
+	}
+
+	return name + "=" + Redacted
+}
+
+var (
+	assignmentRegex = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)=([^\s"']+)`)
+	userinfoRegex   = regexp.MustCompile(`(://[^/\s:@]+):[^/\s@]+@`)
+)
+
+// String redacts the sensitive assignments (NAME=value) and the passwords of URLs found in free text.
+func (r Redactor) String(s string) string {
+	s = assignmentRegex.ReplaceAllStringFunc(s, r.Env)
+
+	return userinfoRegex.ReplaceAllString(s, "$1:"+Redacted+"@")
+}
+
+// Redactor returns the Redactor matching the patterns of the config.
+func (c *Config) Redactor() Redactor {
+	return NewRedactor(c.Redact...)
+}
+
+// Redacted returns a copy of the config safe to display: registry and git credentials are masked
+// as well as the env variables whose name is sensitive.
+func (c *Config) Redacted() *Config {
+	redactor := c.Redactor()
+
+	// The transformations never fail.
+	redacted, _ := c.transform(func(env string) (string, error) {
+		return redactor.String(redactor.Env(env)), nil
+	}, func(credential string) (string, error) {
+		if credential == "" {
+			return "", nil
+		}
+
+		return Redacted, nil
+	})
+
+	if c.Locator != nil {
+		l := *c.Locator
+		l.Remote = withoutUserinfo(l.Remote)
+
+		if l.SSHCommand != "" {
+			l.SSHCommand = Redacted
+		}
+
+		redacted.Locator = &l
+	}
+
+	return redacted
+}
+
+// withoutUserinfo removes the credentials from a git remote URL, such as the token of https://token@host/repo.
+// Remotes that are not URLs, such as git@host:repo, are returned as is.
+func withoutUserinfo(remote string) string {
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.Scheme == "" || parsed.User == nil {
+		return remote
+	}
+
+	parsed.User = nil
+
+	return parsed.String()
+}
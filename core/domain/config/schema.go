@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+// SchemaID is the URL the JSON Schema of vite.yaml is published at.
+const SchemaID = "https://raw.githubusercontent.com/vite-cloud/vite/main/docs/vite.schema.json"
+
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	memorySizeType = reflect.TypeOf(MemorySize(0))
+)
+
+// Schema returns the JSON Schema of vite.yaml, generated from the types it is decoded into.
+func Schema() map[string]any {
+	schema := schemaOf(reflect.TypeOf(configYAML{}))
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "vite.yaml"
+
+	return schema
+}
+
+// schemaOf returns the JSON Schema of the given type.
+func schemaOf(t reflect.Type) map[string]any {
+	switch t {
+	case durationType:
+		// durations are either strings such as 30s or nanoseconds
+		return map[string]any{"type": []string{"string", "integer"}}
+	case memorySizeType:
+		// memory sizes are either strings such as 512m or bytes
+		return map[string]any{"type": []string{"string", "integer"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.Struct:
+		properties := map[string]any{}
+
+		for _, field := range yamlFields(t) {
+			if field.Type.Kind() == reflect.Interface {
+				properties[field.key] = interfaceSchema(field.key)
+				continue
+			}
+
+			properties[field.key] = schemaOf(field.Type)
+		}
+
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": schemaOf(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]any{
+			"type":  "array",
+			"items": schemaOf(t.Elem()),
+		}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// interfaceSchema returns the schema of a key decoded into an interface, as its type can't be inferred.
+func interfaceSchema(key string) map[string]any {
+	switch key {
+	case "registry":
+		// a registry is either the name of a registry or its definition
+		return map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "string"},
+				schemaOf(reflect.TypeOf(registryYAML{})),
+			},
+		}
+	default:
+		return map[string]any{}
+	}
+}
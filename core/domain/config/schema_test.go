@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	assert.Equal(t, schema["$id"], SchemaID)
+	assert.Equal(t, schema["additionalProperties"], false)
+
+	services := schema["properties"].(map[string]any)["services"].(map[string]any)
+	service := services["additionalProperties"].(map[string]any)["properties"].(map[string]any)
+
+	assert.DeepEqual(t, service["image"], map[string]any{"type": "string"})
+	assert.DeepEqual(t, service["requires"], map[string]any{"type": "array", "items": map[string]any{"type": "string"}})
+	assert.Equal(t, len(service["registry"].(map[string]any)["oneOf"].([]any)), 2)
+
+	resources := service["resources"].(map[string]any)["properties"].(map[string]any)
+	assert.DeepEqual(t, resources["memory"], map[string]any{"type": []string{"string", "integer"}})
+}
+
+func TestSchema2(t *testing.T) {
+	published, err := os.ReadFile("../../../docs/vite.schema.json")
+	assert.NilError(t, err)
+
+	expected, err := json.MarshalIndent(Schema(), "", "  ")
+	assert.NilError(t, err)
+
+	assert.Equal(t, string(published), string(expected)+"\n", "docs/vite.schema.json is outdated, run `vite config schema > docs/vite.schema.json`")
+}
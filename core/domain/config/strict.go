@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnknownKey is returned when vite.yaml contains a key that is not part of the config.
+var ErrUnknownKey = errors.New("unknown key")
+
+// unknownFieldRegex matches the errors of the yaml package about unknown keys.
+var unknownFieldRegex = regexp.MustCompile(`^field (\S+) not found in type (.+)$`)
+
+// knownKeys holds the keys of each type vite.yaml is decoded into, by the name of the type.
+var knownKeys = map[string][]string{}
+
+func init() {
+	collectKeys(reflect.TypeOf(configYAML{}))
+	collectKeys(reflect.TypeOf(registryYAML{}))
+}
+
+// collectKeys adds the keys of the given type, and of the types it contains, to knownKeys.
+func collectKeys(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		collectKeys(t.Elem())
+	case reflect.Struct:
+		if _, ok := knownKeys[t.String()]; ok {
+			return
+		}
+
+		var keys []string
+		for _, field := range yamlFields(t) {
+			keys = append(keys, field.key)
+			collectKeys(field.Type)
+		}
+
+		knownKeys[t.String()] = keys
+	}
+}
+
+// yamlField is a field of a struct decoded from YAML.
+type yamlField struct {
+	reflect.StructField
+	key string
+}
+
+// yamlFields returns the exported fields of a struct along with their key in YAML.
+func yamlFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+
+		fields = append(fields, yamlField{StructField: field, key: key})
+	}
+
+	return fields
+}
+
+// decodeStrict decodes a config file, failing on unknown keys and on registries that are neither a name nor a definition.
+// The interpolated contents of the file are decoded instead if not nil.
+func decodeStrict(file configFile, interpolated []byte) error {
+	var c configYAML
+	if interpolated == nil {
+		if err := yaml.UnmarshalStrict(file.Contents, &c); err != nil {
+			return yamlError(file, err)
+		}
+	} else if err := yaml.UnmarshalStrict(interpolated, &c); err != nil {
+		// the interpolated values were encoded again, their lines do not match the ones of the file
+		return yamlError(file, stripLines(err))
+	}
+
+	for name, service := range c.Services {
+		if service == nil {
+			continue
+		}
+
+		switch registry := service.Registry.(type) {
+		case nil, string:
+		case map[interface{}]interface{}:
+			if _, err := decodeRegistry(registry, yaml.UnmarshalStrict); err != nil {
+				// the registry was encoded again, its lines do not match the ones of the file
+				return yamlError(file, stripLines(err))
+			}
+		default:
+			return locateService([]configFile{file}, &ServiceError{
+				Service: name,
+				Err:     fmt.Errorf("registry must be the name of a registry or a registry definition, got %v", registry),
+			})
+		}
+	}
+
+	return nil
+}
+
+// stripLines removes the line numbers from the errors of the yaml package.
+func stripLines(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	stripped := &yaml.TypeError{}
+	for _, message := range typeErr.Errors {
+		stripped.Errors = append(stripped.Errors, yamlLineRegex.ReplaceAllString(message, "$2"))
+	}
+
+	return stripped
+}
+
+// unknownKeyError describes an unknown key, suggesting the closest known key if any.
+func unknownKeyError(key, typeName string) error {
+	if suggestion := suggest(key, knownKeys[typeName]); suggestion != "" {
+		return fmt.Errorf("%w %s (did you mean %s?)", ErrUnknownKey, key, suggestion)
+	}
+
+	return fmt.Errorf("%w %s", ErrUnknownKey, key)
+}
+
+// suggest returns the candidate closest to the given key, if it is close enough to be a typo.
+func suggest(key string, candidates []string) string {
+	best, bestDistance := "", len(key)/2+1
+
+	for _, candidate := range candidates {
+		if distance := levenshtein(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the number of edits needed to turn a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
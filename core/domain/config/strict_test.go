@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		contents string
+		err      string
+	}{
+		{"services:\n  web:\n    image: web\n    hoks:\n      prestart: []\n", "vite.yaml:4:5: unknown key hoks (did you mean hooks?)"},
+		{"services:\n  web:\n    require: [db]\n", "vite.yaml:3:5: unknown key require (did you mean requires?)"},
+		{"services:\n  web:\n    image: web\nproxi:\n  http: 8080\n", "vite.yaml:4:1: unknown key proxi (did you mean proxy?)"},
+		{"services:\n  web:\n    something: true\n", "vite.yaml:3:5: unknown key something"},
+		{"services:\n  web:\n    registry: [docker.io]\n", "vite.yaml:2: service web: registry must be the name of a registry or a registry definition"},
+		{"services:\n  web:\n    registry:\n      usrname: user\n", "unknown key usrname (did you mean username?)"},
+	}
+
+	for _, test := range tests {
+		err := decodeStrict(configFile{Name: "vite.yaml", Contents: []byte(test.contents)}, nil)
+		assert.ErrorContains(t, err, test.err)
+	}
+}
+
+func TestDecodeStrict2(t *testing.T) {
+	err := decodeStrict(configFile{Name: "vite.yaml", Contents: []byte("services:\n  web:\n    hoks: {}\n  db:\n    imag: db\n")}, nil)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// every unknown key is reported, not only the first one
+	assert.ErrorContains(t, err, "vite.yaml:3:5: unknown key hoks")
+	assert.ErrorContains(t, err, "vite.yaml:5:5: unknown key imag (did you mean image?)")
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"image", "hosts", "hooks", "requires"}
+
+	assert.Equal(t, suggest("hoks", candidates), "hooks")
+	assert.Equal(t, suggest("imgae", candidates), "image")
+	assert.Equal(t, suggest("require", candidates), "requires")
+	assert.Equal(t, suggest("database", candidates), "")
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, levenshtein("", ""), 0)
+	assert.Equal(t, levenshtein("hooks", "hooks"), 0)
+	assert.Equal(t, levenshtein("hoks", "hooks"), 1)
+	assert.Equal(t, levenshtein("kitten", "sitting"), 3)
+}
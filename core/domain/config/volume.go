@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Volume is mounted in a service's container.
+type Volume struct {
+	// Source is either the name of a volume managed by vite or, for bind mounts, an absolute path on the host.
+	Source string `json:"source"`
+	// Target is the absolute path at which the volume is mounted in the container.
+	Target string `json:"target"`
+	// ReadOnly mounts the volume as read-only.
+	ReadOnly bool `json:"readOnly"`
+}
+
+// IsBind returns true if the volume is a bind mount of a path on the host.
+func (v Volume) IsBind() bool {
+	return strings.HasPrefix(v.Source, "/")
+}
+
+// ErrInvalidVolume is returned when a volume can not be parsed.
+var ErrInvalidVolume = errors.New("invalid volume, expected <name or absolute path>:<absolute path>[:ro|rw]")
+
+var volumeNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ParseVolume parses a volume written as source:target[:ro|rw].
+func ParseVolume(spec string) (Volume, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Volume{}, fmt.Errorf("%w: %s", ErrInvalidVolume, spec)
+	}
+
+	volume := Volume{
+		Source: parts[0],
+		Target: parts[1],
+	}
+
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "ro":
+			volume.ReadOnly = true
+		case "rw":
+		default:
+			return Volume{}, fmt.Errorf("%w: unknown mode %s", ErrInvalidVolume, parts[2])
+		}
+	}
+
+	if !path.IsAbs(volume.Target) {
+		return Volume{}, fmt.Errorf("%w: target %s must be an absolute path", ErrInvalidVolume, volume.Target)
+	}
+
+	// Relative paths are rejected as the config is not read from the host's filesystem.
+	if !volume.IsBind() && !volumeNameRegex.MatchString(volume.Source) {
+		return Volume{}, fmt.Errorf("%w: source %s must be a volume name or an absolute path", ErrInvalidVolume, volume.Source)
+	}
+
+	return volume, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types"
+	"gopkg.in/yaml.v2"
 )
 
 // configYAML is the YAML representation of the config.
@@ -22,6 +23,10 @@ type configYAML struct {
 		Host string `yaml:"host"`
 	} `yaml:"control_plane"`
 
+	Retention Retention `yaml:"retention"`
+
+	Redact []string `yaml:"redact"`
+
 	configServices map[string]*Service
 }
 
@@ -38,6 +43,18 @@ type serviceYAML struct {
 	Requires []string `yaml:"requires"`
 
 	Registry any `yaml:"registry"`
+
+	Healthcheck *Healthcheck `yaml:"healthcheck"`
+
+	Resources Resources `yaml:"resources"`
+
+	Restart string `yaml:"restart"`
+
+	Volumes []string `yaml:"volumes"`
+
+	Replicas int `yaml:"replicas"`
+
+	LoadBalancing string `yaml:"load_balancing"`
 }
 
 // registryYAML is the YAML representation of a registry
@@ -54,6 +71,24 @@ type registryYAML struct {
 	IdentityToken string `yaml:"identity_token,omitempty"`
 	// RegistryToken is a bearer token to be sent to a registry
 	RegistryToken string `yaml:"registry_token,omitempty"`
+
+	// CredentialHelper is the name of the docker-credential-* program providing the credentials,
+	// e.g. ecr-login for docker-credential-ecr-login.
+	CredentialHelper string `yaml:"credential_helper,omitempty"`
+}
+
+// ServiceError is returned when the configuration of a service is invalid.
+type ServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("service %s: %s", e.Service, e.Err)
+}
+
+func (e *ServiceError) Unwrap() error {
+	return e.Err
 }
 
 func (c configYAML) ToConfig() (*Config, error) {
@@ -73,6 +108,8 @@ func (c configYAML) ToConfig() (*Config, error) {
 	config.Proxy.HTTPS = c.Proxy.HTTPS
 	config.Proxy.HTTP = c.Proxy.HTTP
 	config.ControlPlane.Host = c.ControlPlane.Host
+	config.Retention = c.Retention
+	config.Redact = c.Redact
 
 	if config.Proxy.HTTPS == "" {
 		config.Proxy.HTTPS = "443"
@@ -120,22 +157,84 @@ func (c *configYAML) toConfigService(name string, s *serviceYAML) (*Service, err
 		},
 	}
 
+	// service.Healthcheck
+	if s.Healthcheck != nil {
+		if err := s.Healthcheck.Validate(); err != nil {
+			return nil, &ServiceError{Service: name, Err: err}
+		}
+
+		service.Healthcheck = s.Healthcheck
+	}
+
+	// service.Resources
+	if err := s.Resources.Validate(); err != nil {
+		return nil, &ServiceError{Service: name, Err: err}
+	}
+	service.Resources = s.Resources
+
+	// service.Restart
+	restart, err := ParseRestartPolicy(s.Restart)
+	if err != nil {
+		return nil, &ServiceError{Service: name, Err: err}
+	}
+	service.Restart = restart
+
+	// service.Volumes
+	for _, spec := range s.Volumes {
+		volume, err := ParseVolume(spec)
+		if err != nil {
+			return nil, &ServiceError{Service: name, Err: err}
+		}
+
+		service.Volumes = append(service.Volumes, volume)
+	}
+
+	// service.Replicas
+	if s.Replicas < 0 {
+		return nil, &ServiceError{Service: name, Err: fmt.Errorf("%w: %d", ErrInvalidReplicas, s.Replicas)}
+	}
+
+	service.Replicas = s.Replicas
+	if service.Replicas == 0 {
+		service.Replicas = 1
+	}
+
+	// service.LoadBalancing
+	service.LoadBalancing, err = ParseLoadBalancing(s.LoadBalancing)
+	if err != nil {
+		return nil, &ServiceError{Service: name, Err: err}
+	}
+
 	// service.Registry
 	if s.Registry != nil {
-		switch s.Registry.(type) {
+		var registry *registryYAML
+
+		switch value := s.Registry.(type) {
 		case string:
-			if _, ok := c.Registries[s.Registry.(string)]; !ok {
-				return nil, fmt.Errorf("registry %s not found", s.Registry.(string))
+			if _, ok := c.Registries[value]; !ok {
+				return nil, &ServiceError{Service: name, Err: fmt.Errorf("registry %s not found", value)}
 			}
 
-			service.Registry = c.toConfigRegistry(c.Registries[s.Registry.(string)])
+			registry = c.Registries[value]
 		case *registryYAML:
-			registry := s.Registry.(*registryYAML)
-
-			service.Registry = c.toConfigRegistry(registry)
+			registry = value
+		case map[interface{}]interface{}:
+			// inline registries are decoded as maps by the yaml package
+			if registry, err = decodeRegistry(value, yaml.Unmarshal); err != nil {
+				return nil, &ServiceError{Service: name, Err: fmt.Errorf("invalid registry: %w", err)}
+			}
 		default:
-			return nil, fmt.Errorf("invalid registry type %T (%v)", s.Registry, s.Registry)
+			return nil, &ServiceError{Service: name, Err: fmt.Errorf("invalid registry type %T (%v)", s.Registry, s.Registry)}
+		}
+
+		if registry.CredentialHelper != "" {
+			if err = ValidateCredentialHelper(registry.CredentialHelper); err != nil {
+				return nil, &ServiceError{Service: name, Err: err}
+			}
 		}
+
+		service.Registry = c.toConfigRegistry(registry)
+		service.CredentialHelper = registry.CredentialHelper
 	}
 
 	// service.Hosts
@@ -160,7 +259,7 @@ func (c *configYAML) toConfigService(name string, s *serviceYAML) (*Service, err
 	// service.Requires
 	for _, require := range s.Requires {
 		if _, ok := c.Services[require]; !ok {
-			return nil, fmt.Errorf("service %s not found, %s can not depend on it", require, name)
+			return nil, &ServiceError{Service: name, Err: fmt.Errorf("requires service %s which does not exist", require)}
 		}
 
 		converted, err := c.toConfigService(require, c.Services[require])
@@ -174,6 +273,21 @@ func (c *configYAML) toConfigService(name string, s *serviceYAML) (*Service, err
 	return c.configServices[name], nil
 }
 
+// decodeRegistry decodes an inline registry definition using the given unmarshal function.
+func decodeRegistry(registry map[interface{}]interface{}, unmarshal func([]byte, any) error) (*registryYAML, error) {
+	contents, err := yaml.Marshal(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &registryYAML{}
+	if err = unmarshal(contents, decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
 func (c configYAML) toConfigRegistry(r *registryYAML) *types.AuthConfig {
 	return &types.AuthConfig{
 		Username:      r.Username,
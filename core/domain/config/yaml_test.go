@@ -2,8 +2,10 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	"gopkg.in/yaml.v2"
 	"gotest.tools/v3/assert"
 )
 
@@ -28,9 +30,11 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
-						Image:      "nginx:latest",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
+						Image:         "nginx:latest",
 					},
 				},
 			},
@@ -47,9 +51,11 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
-						Hosts:      []string{"example.com", "example.org"},
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
+						Hosts:         []string{"example.com", "example.org"},
 					},
 				},
 			},
@@ -66,8 +72,10 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Hosts: []string{
 							"example.com",
 							"www.example.com",
@@ -93,8 +101,10 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Hooks: Hooks{
 							Prestart:  []string{"prestart_hook"},
 							Poststart: []string{"poststart_hook1", "poststart_hook2"},
@@ -117,8 +127,10 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Env: []string{
 							"FOO=bar",
 							"BAR=baz",
@@ -144,8 +156,10 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Registry: &types.AuthConfig{
 							ServerAddress: "docker.io",
 						},
@@ -169,8 +183,10 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"example": {
-						IsTopLevel: true,
-						Name:       "example",
+						IsTopLevel:    true,
+						Name:          "example",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Registry: &types.AuthConfig{
 							ServerAddress: "registry.vite.cloud",
 							Username:      "foo",
@@ -215,18 +231,24 @@ func TestConfigYAML_ToConfig(t *testing.T) {
 			want: &Config{
 				Services: map[string]*Service{
 					"first": {
-						IsTopLevel: true,
-						Name:       "first",
+						IsTopLevel:    true,
+						Name:          "first",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 						Requires: []*Service{
 							{
-								IsTopLevel: false,
-								Name:       "second",
+								IsTopLevel:    false,
+								Name:          "second",
+								Replicas:      1,
+								LoadBalancing: RoundRobin,
 							},
 						},
 					},
 					"second": {
-						IsTopLevel: false,
-						Name:       "second",
+						IsTopLevel:    false,
+						Name:          "second",
+						Replicas:      1,
+						LoadBalancing: RoundRobin,
 					},
 				},
 			},
@@ -319,3 +341,196 @@ func TestConfigYAML_ToConfig3(t *testing.T) {
 	assert.Equal(t, got.Services["a"].Requires[0], got.Services["a"])
 
 }
+
+func TestConfigYAML_ToConfig4(t *testing.T) {
+	var c configYAML
+	err := yaml.Unmarshal([]byte("retention:\n  keep: 3\n  max_age: 72h\n"), &c)
+	assert.NilError(t, err)
+
+	got, err := c.ToConfig()
+	assert.NilError(t, err)
+
+	assert.Equal(t, got.Retention.Keep, 3)
+	assert.Equal(t, got.Retention.MaxAge, 72*time.Hour)
+	assert.Assert(t, got.Retention.IsEnabled())
+	assert.Assert(t, !Retention{}.IsEnabled())
+}
+
+func TestConfigYAML_ToConfig5(t *testing.T) {
+	var c configYAML
+	err := yaml.Unmarshal([]byte(`services:
+  api:
+    image: nginx
+    healthcheck:
+      http:
+        path: /health
+        port: 8080
+      interval: 5s
+      timeout: 2s
+      retries: 4
+      start_period: 10s
+`), &c)
+	assert.NilError(t, err)
+
+	got, err := c.ToConfig()
+	assert.NilError(t, err)
+
+	healthcheck := got.Services["api"].Healthcheck
+	assert.Assert(t, healthcheck != nil)
+	assert.Equal(t, healthcheck.HTTP.Path, "/health")
+	assert.Equal(t, healthcheck.HTTP.Port, 8080)
+	assert.Equal(t, healthcheck.Interval, 5*time.Second)
+	assert.Equal(t, healthcheck.Timeout, 2*time.Second)
+	assert.Equal(t, healthcheck.Retries, 4)
+	assert.Equal(t, healthcheck.StartPeriod, 10*time.Second)
+}
+
+func TestConfigYAML_ToConfig6(t *testing.T) {
+	// a healthcheck must have exactly one kind of check
+	config := &configYAML{
+		Services: map[string]*serviceYAML{
+			"a": {
+				Healthcheck: &Healthcheck{
+					TCP:     &TCPCheck{Port: 5432},
+					Command: "pg_isready",
+				},
+			},
+		},
+	}
+
+	_, err := config.ToConfig()
+	assert.ErrorIs(t, err, ErrInvalidHealthcheck)
+	assert.ErrorContains(t, err, "service a: ")
+}
+
+func TestConfigYAML_ToConfig7(t *testing.T) {
+	var c configYAML
+	err := yaml.Unmarshal([]byte(`services:
+  api:
+    image: nginx
+    restart: on-failure:3
+    resources:
+      memory: 512m
+      memory_reservation: 268435456
+      cpu_shares: 512
+      pids_limit: 100
+`), &c)
+	assert.NilError(t, err)
+
+	got, err := c.ToConfig()
+	assert.NilError(t, err)
+
+	service := got.Services["api"]
+	assert.Equal(t, service.Resources.Memory, MemorySize(512*1024*1024))
+	assert.Equal(t, service.Resources.MemoryReservation, MemorySize(256*1024*1024))
+	assert.Equal(t, service.Resources.CPUShares, int64(512))
+	assert.Equal(t, service.Resources.PidsLimit, int64(100))
+	assert.Equal(t, service.Restart.Name, "on-failure")
+	assert.Equal(t, service.Restart.MaximumRetryCount, 3)
+}
+
+func TestConfigYAML_ToConfig8(t *testing.T) {
+	config := &configYAML{
+		Services: map[string]*serviceYAML{
+			"a": {Restart: "sometimes"},
+		},
+	}
+
+	_, err := config.ToConfig()
+	assert.ErrorIs(t, err, ErrInvalidRestartPolicy)
+	assert.ErrorContains(t, err, "service a: ")
+
+	var size MemorySize
+	err = yaml.Unmarshal([]byte(`lots`), &size)
+	assert.ErrorContains(t, err, "invalid size")
+}
+
+func TestConfigYAML_ToConfig9(t *testing.T) {
+	config := &configYAML{
+		Services: map[string]*serviceYAML{
+			"db": {Volumes: []string{"pgdata:/var/lib/postgresql/data", "/etc/ssl:/etc/ssl:ro"}},
+		},
+	}
+
+	got, err := config.ToConfig()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.Services["db"].Volumes, []Volume{
+		{Source: "pgdata", Target: "/var/lib/postgresql/data"},
+		{Source: "/etc/ssl", Target: "/etc/ssl", ReadOnly: true},
+	})
+
+	config.Services["db"].Volumes = []string{"pgdata"}
+	config.configServices = nil
+
+	_, err = config.ToConfig()
+	assert.ErrorIs(t, err, ErrInvalidVolume)
+	assert.ErrorContains(t, err, "service db: ")
+}
+
+func TestConfigYAML_ToConfig10(t *testing.T) {
+	conf, err := parseFiles([]configFile{{Name: "vite.yaml", Contents: []byte(`
+services:
+  app:
+    image: app:1.0
+    registry:
+      host: registry.example.com
+      username: me
+      password: secret
+  worker:
+    image: worker:1.0
+    registry: ecr
+registries:
+  ecr:
+    host: 123456789.dkr.ecr.eu-west-1.amazonaws.com
+    credential_helper: ecr-login
+`)}})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, conf.Services["app"].Registry, &types.AuthConfig{
+		ServerAddress: "registry.example.com",
+		Username:      "me",
+		Password:      "secret",
+	})
+	assert.Equal(t, conf.Services["app"].CredentialHelper, "")
+
+	assert.Equal(t, conf.Services["worker"].Registry.ServerAddress, "123456789.dkr.ecr.eu-west-1.amazonaws.com")
+	assert.Equal(t, conf.Services["worker"].CredentialHelper, "ecr-login")
+
+	for _, helper := range []string{"../../bin/sh", "ecr-login/..", "..", "ecr login"} {
+		_, err = parseFiles([]configFile{{Name: "vite.yaml", Contents: []byte(`
+services:
+  worker:
+    image: worker:1.0
+    registry:
+      host: 123456789.dkr.ecr.eu-west-1.amazonaws.com
+      credential_helper: "` + helper + `"
+`)}})
+		assert.ErrorIs(t, err, ErrInvalidCredentialHelper)
+		assert.ErrorContains(t, err, "service worker: ")
+	}
+}
+
+func TestConfigYAML_ToConfig11(t *testing.T) {
+	config := &configYAML{
+		Services: map[string]*serviceYAML{
+			"api":    {Replicas: 3, LoadBalancing: "least_connections"},
+			"worker": {},
+		},
+	}
+
+	got, err := config.ToConfig()
+	assert.NilError(t, err)
+	assert.Equal(t, got.Services["api"].Replicas, 3)
+	assert.Equal(t, got.Services["api"].LoadBalancing, LeastConnections)
+	assert.Equal(t, got.Services["worker"].Replicas, 1)
+	assert.Equal(t, got.Services["worker"].LoadBalancing, RoundRobin)
+
+	config = &configYAML{Services: map[string]*serviceYAML{"api": {Replicas: -1}}}
+	_, err = config.ToConfig()
+	assert.ErrorIs(t, err, ErrInvalidReplicas)
+	assert.ErrorContains(t, err, "service api: ")
+
+	config = &configYAML{Services: map[string]*serviceYAML{"api": {LoadBalancing: "random"}}}
+	_, err = config.ToConfig()
+	assert.ErrorIs(t, err, ErrInvalidLoadBalancing)
+}
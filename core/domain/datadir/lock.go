@@ -0,0 +1,104 @@
+package datadir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrLocked is returned by TryLock when the lock is held by someone else.
+var ErrLocked = errors.New("locked by another process")
+
+// Lock is an advisory lock on a file of a Store.
+// It is shared between processes, so it may be used to serialize
+// access to files that are updated by concurrent vite commands.
+type Lock struct {
+	file *os.File
+}
+
+// Lock acquires an exclusive lock with the given name, waiting for it to be released if needed.
+func (s Store) Lock(name string) (*Lock, error) {
+	return s.lock(name, syscall.LOCK_EX)
+}
+
+// RLock acquires a shared lock with the given name, waiting for an exclusive lock to be released if needed.
+// Any number of shared locks may be held at once, TryLock fails while one of them is.
+func (s Store) RLock(name string) (*Lock, error) {
+	return s.lock(name, syscall.LOCK_SH)
+}
+
+// TryLock acquires an exclusive lock with the given name or returns ErrLocked
+// if it is already held.
+func (s Store) TryLock(name string) (*Lock, error) {
+	return s.lock(name, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func (s Store) lock(name string, how int) (*Lock, error) {
+	file, err := s.Open(name+".lock", os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = syscall.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+
+		return nil, err
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return err
+	}
+
+	return l.file.Close()
+}
+
+// WriteFile atomically replaces the file at the given path of the Store with data.
+// The data is written to a temporary file that is then renamed over the
+// destination so that readers never see a partially written file.
+func (s Store) WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir, err := s.Dir()
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(dir, path)
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dest)
+}
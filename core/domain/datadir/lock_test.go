@@ -0,0 +1,110 @@
+package datadir
+
+import (
+	"gotest.tools/v3/assert"
+	"os"
+	"testing"
+)
+
+func TestStore_TryLock(t *testing.T) {
+	defer resetDataDir()
+
+	UseTestHome(t)
+
+	lock, err := Store("this").TryLock("test")
+	assert.NilError(t, err)
+
+	_, err = Store("this").TryLock("test")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	err = lock.Unlock()
+	assert.NilError(t, err)
+
+	lock, err = Store("this").TryLock("test")
+	assert.NilError(t, err)
+
+	err = lock.Unlock()
+	assert.NilError(t, err)
+}
+
+func TestStore_Lock(t *testing.T) {
+	defer resetDataDir()
+
+	UseTestHome(t)
+
+	lock, err := Store("this").Lock("test")
+	assert.NilError(t, err)
+
+	acquired := make(chan struct{})
+
+	go func() {
+		lock, err := Store("this").Lock("test")
+		assert.NilError(t, err)
+		close(acquired)
+
+		assert.NilError(t, lock.Unlock())
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	default:
+	}
+
+	err = lock.Unlock()
+	assert.NilError(t, err)
+
+	<-acquired
+}
+
+func TestStore_RLock(t *testing.T) {
+	defer resetDataDir()
+
+	UseTestHome(t)
+
+	first, err := Store("this").RLock("test")
+	assert.NilError(t, err)
+
+	second, err := Store("this").RLock("test")
+	assert.NilError(t, err)
+
+	_, err = Store("this").TryLock("test")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	assert.NilError(t, first.Unlock())
+	assert.NilError(t, second.Unlock())
+
+	lock, err := Store("this").TryLock("test")
+	assert.NilError(t, err)
+
+	err = lock.Unlock()
+	assert.NilError(t, err)
+}
+
+func TestStore_WriteFile(t *testing.T) {
+	defer resetDataDir()
+
+	UseTestHome(t)
+
+	err := Store("this").WriteFile("file.json", []byte("first"), 0600)
+	assert.NilError(t, err)
+
+	err = Store("this").WriteFile("file.json", []byte("second"), 0600)
+	assert.NilError(t, err)
+
+	dir, err := Store("this").Dir()
+	assert.NilError(t, err)
+
+	contents, err := os.ReadFile(dir + "/file.json")
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "second")
+
+	stat, err := os.Stat(dir + "/file.json")
+	assert.NilError(t, err)
+	assert.Equal(t, stat.Mode().Perm(), os.FileMode(0600))
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+}
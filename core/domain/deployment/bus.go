@@ -1,19 +1,35 @@
 package deployment
 
-import "github.com/vite-cloud/vite/core/domain/config"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"strings"
+	"time"
+)
 
 const (
 	ErrorEvent  = "Error"
 	FinishEvent = "Finish"
 )
 
+// EventSchemaVersion is the version of the JSON encoding of events.
+// It must be incremented whenever a payload changes in a backward incompatible way.
+const EventSchemaVersion = 1
+
+// ErrUnsupportedEventVersion is returned when decoding an event encoded by a newer version of vite.
+var ErrUnsupportedEventVersion = errors.New("unsupported event schema version")
+
 type Event struct {
 	// Service is the name of the service that the event is about.
 	Service *config.Service
 	// ID is an identifier unique to the kind of the event.
 	ID string
-	// Data is the payload of the event.
-	Data any
+	// Data is the payload of the event, its type depends on the ID of the event.
+	Data Payload
+	// Time is the time the event was emitted, it is set once the event is recorded.
+	Time time.Time
 }
 
 // IsError returns true if the event is an error event.
@@ -38,3 +54,252 @@ func (e Event) Label() string {
 func (e Event) IsFinish() bool {
 	return e.ID == FinishEvent
 }
+
+// String renders the event for humans, it is shared by every consumer of events.
+func (e Event) String() string {
+	if e.Data == nil {
+		return fmt.Sprintf("%s(%s)", e.Label(), e.ID)
+	}
+
+	return fmt.Sprintf("%s(%s): %s", e.Label(), e.ID, e.Data)
+}
+
+// eventJSON is the stable JSON encoding of an Event.
+type eventJSON struct {
+	Version int             `json:"version"`
+	Time    time.Time       `json:"time"`
+	ID      string          `json:"id"`
+	Service string          `json:"service,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e Event) MarshalJSON() ([]byte, error) {
+	encoded := eventJSON{
+		Version: EventSchemaVersion,
+		Time:    e.Time,
+		ID:      e.ID,
+	}
+
+	if e.Service != nil {
+		encoded.Service = e.Service.Name
+	}
+
+	if e.Data != nil {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded.Data = data
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Only the name of the service is decoded.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var decoded eventJSON
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	if decoded.Version > EventSchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedEventVersion, decoded.Version)
+	}
+
+	e.Time = decoded.Time
+	e.ID = decoded.ID
+	e.Service = nil
+	e.Data = nil
+
+	if decoded.Service != "" {
+		e.Service = &config.Service{Name: decoded.Service}
+	}
+
+	if len(decoded.Data) == 0 {
+		return nil
+	}
+
+	payload, ok := payloads[decoded.ID]
+	if !ok {
+		return fmt.Errorf("unknown event %s", decoded.ID)
+	}
+
+	e.Data, err = payload(decoded.Data)
+
+	return err
+}
+
+// Payload is the data carried by an event.
+type Payload interface {
+	// String returns a human-readable representation of the payload.
+	String() string
+}
+
+// payloads decodes the payload of each kind of event.
+var payloads = map[string]func(data []byte) (Payload, error){
+	ErrorEvent:           decodePayload[ErrorPayload],
+	StartEvent:           decodePayload[DeploymentPayload],
+	RollbackDeployment:   decodePayload[DeploymentPayload],
+	RemoveDeployment:     decodePayload[DeploymentPayload],
+	StartLayerDeployment: decodePayload[LayerPayload],
+	PullImage:            decodePayload[ImagePayload],
+	CreateContainer:      decodePayload[ContainerPayload],
+	StartContainer:       decodePayload[ContainerPayload],
+	StopContainer:        decodePayload[ContainerPayload],
+	RemoveContainer:      decodePayload[ContainerPayload],
+	RunHook:              decodePayload[HooksPayload],
+	ConnectDependency:    decodePayload[DependencyPayload],
+	AcquireSubnet:        decodePayload[SubnetPayload],
+	ReleaseSubnet:        decodePayload[SubnetPayload],
+	CreateNetwork:        decodePayload[NetworkPayload],
+	RemoveNetwork:        decodePayload[NetworkPayload],
+	CreateVolume:         decodePayload[VolumePayload],
+	ReplicaReady:         decodePayload[ReplicaPayload],
+}
+
+func decodePayload[T Payload](data []byte) (Payload, error) {
+	var payload T
+
+	err := json.Unmarshal(data, &payload)
+
+	return payload, err
+}
+
+// ErrorPayload is the payload of ErrorEvent.
+// The container's state and logs are only set if a container failed to start.
+type ErrorPayload struct {
+	Message   string   `json:"message"`
+	ExitCode  *int     `json:"exitCode,omitempty"`
+	OOMKilled bool     `json:"oomKilled,omitempty"`
+	Logs      []string `json:"logs,omitempty"`
+}
+
+// NewErrorPayload returns the payload describing the given error.
+// Sensitive values are redacted from the message and logs as they end up in the event log.
+func NewErrorPayload(err error) ErrorPayload {
+	redactor := config.NewRedactor()
+
+	payload := ErrorPayload{Message: redactor.String(err.Error())}
+
+	var containerErr *ContainerError
+	if errors.As(err, &containerErr) {
+		payload.ExitCode = &containerErr.ExitCode
+		payload.OOMKilled = containerErr.OOMKilled
+
+		for _, line := range containerErr.Logs {
+			payload.Logs = append(payload.Logs, redactor.String(line))
+		}
+	}
+
+	return payload
+}
+
+func (p ErrorPayload) String() string {
+	if len(p.Logs) == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%s, last logs:\n  | %s", p.Message, strings.Join(p.Logs, "\n  | "))
+}
+
+// DeploymentPayload is the payload of the events related to a whole deployment.
+type DeploymentPayload struct {
+	Deployment string `json:"deployment"`
+}
+
+func (p DeploymentPayload) String() string {
+	return p.Deployment
+}
+
+// LayerPayload is the payload of StartLayerDeployment.
+type LayerPayload struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+func (p LayerPayload) String() string {
+	return fmt.Sprintf("layer %d/%d", p.Current, p.Total)
+}
+
+// ImagePayload is the payload of PullImage.
+type ImagePayload struct {
+	Image string `json:"image"`
+}
+
+func (p ImagePayload) String() string {
+	return p.Image
+}
+
+// ContainerPayload is the payload of the events related to a container.
+type ContainerPayload struct {
+	Container string `json:"container"`
+}
+
+func (p ContainerPayload) String() string {
+	return p.Container
+}
+
+// HooksPayload is the payload of RunHook.
+type HooksPayload struct {
+	Commands []string `json:"commands"`
+}
+
+func (p HooksPayload) String() string {
+	if len(p.Commands) == 0 {
+		return "no hooks"
+	}
+
+	return strings.Join(p.Commands, "; ")
+}
+
+// DependencyPayload is the payload of ConnectDependency.
+type DependencyPayload struct {
+	Service string `json:"service"`
+}
+
+func (p DependencyPayload) String() string {
+	return fmt.Sprintf("connected service %s to the service's network", p.Service)
+}
+
+// SubnetPayload is the payload of the events related to a subnet.
+type SubnetPayload struct {
+	Subnet string `json:"subnet"`
+}
+
+func (p SubnetPayload) String() string {
+	return p.Subnet
+}
+
+// NetworkPayload is the payload of the events related to a network.
+type NetworkPayload struct {
+	Network string `json:"network"`
+}
+
+func (p NetworkPayload) String() string {
+	return p.Network
+}
+
+// VolumePayload is the payload of CreateVolume.
+type VolumePayload struct {
+	Volume string `json:"volume"`
+}
+
+func (p VolumePayload) String() string {
+	return p.Volume
+}
+
+// ReplicaPayload is the payload of ReplicaReady.
+type ReplicaPayload struct {
+	Replica   int    `json:"replica"`
+	Replicas  int    `json:"replicas"`
+	Container string `json:"container"`
+}
+
+func (p ReplicaPayload) String() string {
+	return fmt.Sprintf("replica %d/%d is running (%s)", p.Replica, p.Replicas, p.Container)
+}
@@ -1,9 +1,13 @@
 package deployment
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/vite-cloud/vite/core/domain/config"
 	"gotest.tools/v3/assert"
 	"testing"
+	"time"
 )
 
 func TestEvent_IsError(t *testing.T) {
@@ -29,3 +33,62 @@ func TestEvent_Label(t *testing.T) {
 	event = Event{Service: &config.Service{Name: "test"}}
 	assert.Assert(t, event.Label() == "test")
 }
+
+func TestEvent_String(t *testing.T) {
+	event := Event{ID: PullImage, Service: &config.Service{Name: "api"}, Data: ImagePayload{Image: "nginx"}}
+	assert.Equal(t, event.String(), "api(PullImage): nginx")
+
+	event = Event{ID: FinishEvent}
+	assert.Equal(t, event.String(), "global(Finish)")
+}
+
+func TestEvent_MarshalJSON(t *testing.T) {
+	event := Event{
+		ID:      StartLayerDeployment,
+		Service: &config.Service{Name: "api"},
+		Data:    LayerPayload{Current: 1, Total: 2},
+		Time:    time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	got, err := json.Marshal(event)
+	assert.NilError(t, err)
+	assert.Equal(t, string(got), `{"version":1,"time":"2022-06-01T12:00:00Z","id":"StartLayerDeployment","service":"api","data":{"current":1,"total":2}}`)
+
+	var decoded Event
+	err = json.Unmarshal(got, &decoded)
+	assert.NilError(t, err)
+	assert.Equal(t, decoded.ID, event.ID)
+	assert.Equal(t, decoded.Label(), "api")
+	assert.Equal(t, decoded.Data, event.Data)
+	assert.Assert(t, decoded.Time.Equal(event.Time))
+}
+
+func TestEvent_UnmarshalJSON(t *testing.T) {
+	var event Event
+
+	err := json.Unmarshal([]byte(`{"version":2,"id":"Finish"}`), &event)
+	assert.ErrorIs(t, err, ErrUnsupportedEventVersion)
+
+	err = json.Unmarshal([]byte(`{"version":1,"id":"Unknown","data":{}}`), &event)
+	assert.ErrorContains(t, err, "unknown event Unknown")
+}
+
+func TestNewErrorPayload(t *testing.T) {
+	payload := NewErrorPayload(errors.New("could not pull image"))
+	assert.Equal(t, payload.String(), "could not pull image")
+	assert.Assert(t, payload.ExitCode == nil)
+
+	err := fmt.Errorf("%w (cleanup failed: timeout)", &ContainerError{
+		Err:       ErrContainerNotRunning,
+		ExitCode:  137,
+		OOMKilled: true,
+		Logs:      []string{"starting", "allocating memory"},
+	})
+
+	payload = NewErrorPayload(err)
+	assert.Equal(t, *payload.ExitCode, 137)
+	assert.Assert(t, payload.OOMKilled)
+	assert.DeepEqual(t, payload.Logs, []string{"starting", "allocating memory"})
+	assert.Equal(t, payload.String(), "container is not running (exit code 137, out of memory) (cleanup failed: timeout), last logs:\n  | starting\n  | allocating memory")
+	assert.Assert(t, errors.Is(err, ErrContainerNotRunning))
+}
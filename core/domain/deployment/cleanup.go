@@ -0,0 +1,320 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/client"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/log"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"os"
+	"path/filepath"
+)
+
+const (
+	StopContainer    = "StopContainer"
+	RemoveContainer  = "RemoveContainer"
+	RemoveNetwork    = "RemoveNetwork"
+	ReleaseSubnet    = "ReleaseSubnet"
+	RemoveDeployment = "RemoveDeployment"
+)
+
+// Teardown stops and removes every container, network and subnet created by the deployment.
+// Each resource is removed from the manifest once released, so tearing down a deployment again,
+// such as pruning a rolled back one, never touches resources that now belong to another deployment.
+// Containers are removed in the reverse order of their creation so that services are
+// stopped before the services they depend on. Events are sent to the given channel, if any.
+func (d *Deployment) Teardown(ctx context.Context, events chan<- Event) error {
+	if d.Docker == nil {
+		docker, err := runtime.NewClient()
+		if err != nil {
+			return err
+		}
+
+		d.Docker = docker
+	}
+
+	// The config is only needed to run the prestop and poststop hooks, if it can not
+	// be read anymore, we still want to remove the resources created by the deployment.
+	conf, err := config.Get(d.Locator)
+	if err != nil {
+		log.Log(zoup.WarnLevel, "could not read config, skipping hooks", zoup.Fields{
+			"deployment": d.ID(),
+			"err":        err,
+		})
+	} else if conf, err = conf.Expand(secret.Expand); err != nil {
+		// Hooks must not run with references to secrets instead of their values.
+		log.Log(zoup.WarnLevel, "could not expand secrets, skipping hooks", zoup.Fields{
+			"deployment": d.ID(),
+			"err":        err,
+		})
+	}
+
+	containers, _ := d.Get("created_containers")
+
+	for i := len(containers) - 1; i >= 0; i-- {
+		var service *config.Service
+		if conf != nil {
+			service = conf.Services[containers[i].Label]
+		}
+
+		id, err := containerID(containers[i].Value)
+		if err != nil {
+			return fmt.Errorf("%w for service %s", err, containers[i].Label)
+		}
+
+		if err = d.removeContainer(ctx, events, id, service); err != nil {
+			return err
+		}
+
+		d.forget("created_containers", containers[:i])
+	}
+
+	d.forget("ready_containers", nil)
+
+	networks, _ := d.Get("network")
+
+	for i, network := range networks {
+		err = d.Docker.NetworkRemove(ctx, network.Value.(string))
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+
+		d.forget("network", networks[i+1:])
+
+		emit(events, Event{
+			ID:      RemoveNetwork,
+			Service: serviceNamed(conf, network.Label),
+			Data:    NetworkPayload{Network: network.Value.(string)},
+		})
+	}
+
+	subnets, _ := d.Get("subnet")
+	if len(subnets) == 0 {
+		return nil
+	}
+
+	subnetter, err := runtime.NewSubnetManager()
+	if err != nil {
+		return err
+	}
+
+	for i, subnet := range subnets {
+		if err = subnetter.Release(subnet.Value.(string)); err != nil {
+			return err
+		}
+
+		// A released subnet may be allocated to the next deployment right away,
+		// it must never be released again by a later teardown of this deployment.
+		d.forget("subnet", subnets[i+1:])
+
+		emit(events, Event{
+			ID:      ReleaseSubnet,
+			Service: serviceNamed(conf, subnet.Label),
+			Data:    SubnetPayload{Subnet: subnet.Value.(string)},
+		})
+	}
+
+	return nil
+}
+
+// removeContainer runs the prestop hooks, stops the container, runs the poststop hooks
+// and removes the given container of the service.
+// The service is nil if the config could not be read.
+func (d *Deployment) removeContainer(ctx context.Context, events chan<- Event, id string, service *config.Service) error {
+	info, err := d.Docker.ContainerInspect(ctx, id)
+	if client.IsErrNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if service != nil && info.State.Running {
+		if err = d.RunHooks(ctx, id, service.Hooks.Prestop); err != nil {
+			return err
+		}
+
+		emit(events, Event{
+			ID:      RunHook,
+			Service: service,
+			Data:    HooksPayload{Commands: service.Hooks.Prestop},
+		})
+	}
+
+	if err = d.Docker.ContainerStop(ctx, id); err != nil {
+		return err
+	}
+
+	emit(events, Event{
+		ID:      StopContainer,
+		Service: service,
+		Data:    ContainerPayload{Container: id},
+	})
+
+	// The container is stopped, so the poststop hooks run in one-off
+	// containers created from the service's image instead.
+	if service != nil {
+		for _, command := range service.Hooks.Poststop {
+			err = d.Docker.ContainerRun(ctx, service.Image, command, runtime.ContainerCreateOptions{
+				Env:      service.Env,
+				Registry: service.Registry,
+				Labels: map[string]string{
+					"cloud.vite.service":    service.Name,
+					"cloud.vite.deployment": d.ID(),
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		emit(events, Event{
+			ID:      RunHook,
+			Service: service,
+			Data:    HooksPayload{Commands: service.Hooks.Poststop},
+		})
+	}
+
+	if err = d.Docker.ContainerRemove(ctx, id); err != nil {
+		return err
+	}
+
+	emit(events, Event{
+		ID:      RemoveContainer,
+		Service: service,
+		Data:    ContainerPayload{Container: id},
+	})
+
+	return nil
+}
+
+// Cleanup tears down the deployment and removes its manifest and event log.
+func (d *Deployment) Cleanup(ctx context.Context, events chan<- Event) error {
+	err := d.Teardown(ctx, events)
+	if err != nil {
+		return fmt.Errorf("could not cleanup deployment %s: %w", d.ID(), err)
+	}
+
+	err = resource.Delete[*Deployment](Store, d, func(d *Deployment) string {
+		return d.ID()
+	})
+	if err != nil {
+		return err
+	}
+
+	path, err := EventLogPath(d.ID())
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	dir, err := Store.Dir()
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(filepath.Join(dir, servedLock(d.ID())+".lock")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	emit(events, Event{
+		ID:   RemoveDeployment,
+		Data: DeploymentPayload{Deployment: d.ID()},
+	})
+
+	return nil
+}
+
+// forget replaces the resources stored under the given key with the ones that were not released yet.
+func (d *Deployment) forget(key string, remaining []LabeledValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(remaining) == 0 {
+		d.Resources.Delete(key)
+		return
+	}
+
+	d.Resources.Store(key, remaining)
+}
+
+// ErrDeploymentInUse is returned when trying to clean up the deployment served by the proxy.
+var ErrDeploymentInUse = errors.New("deployment is currently in use by the proxy")
+
+// servedLock returns the name of the lock shared by the proxies serving the deployment with the given ID.
+func servedLock(id string) string {
+	return "served-" + id
+}
+
+// Serve records that the deployment is served by a proxy until the returned lock is released, see InUse.
+func (d *Deployment) Serve() (*datadir.Lock, error) {
+	return Store.RLock(servedLock(d.ID()))
+}
+
+// ServeInProgress is Serve for a deployment in progress, whose ready replicas are served before it succeeds.
+// It returns ErrNoDeploymentInProgress if the deployment is over, as the replicas of a failed deployment
+// are removed as soon as no proxy serves them, see waitUnserved.
+func (d *Deployment) ServeInProgress() (*datadir.Lock, error) {
+	lock, err := d.Serve()
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := resource.Get[Deployment](Store, d.ID())
+	if err == nil && saved.Status != StatusRunning {
+		err = ErrNoDeploymentInProgress
+	}
+
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// waitUnserved waits for the proxies serving the deployment to drain the requests to its replicas.
+func (d *Deployment) waitUnserved() error {
+	lock, err := Store.Lock(servedLock(d.ID()))
+	if err != nil {
+		return err
+	}
+
+	return lock.Unlock()
+}
+
+// InUse returns whether a proxy serves the deployment, including proxies pinned to it by `vite proxy run <id>`.
+func (d *Deployment) InUse() (bool, error) {
+	lock, err := Store.TryLock(servedLock(d.ID()))
+	if errors.Is(err, datadir.ErrLocked) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return false, lock.Unlock()
+}
+
+// emit sends an event to the channel, if any.
+func emit(events chan<- Event, event Event) {
+	if events != nil {
+		events <- event
+	}
+}
+
+// serviceNamed returns the service with the given name or nil if the config is not available.
+func serviceNamed(conf *config.Config, name string) *config.Service {
+	if conf == nil {
+		return nil
+	}
+
+	return conf.Services[name]
+}
@@ -6,17 +6,35 @@ import (
 	"errors"
 	"fmt"
 	"github.com/vite-cloud/vite/core/domain/locator"
+	"io"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 
 	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/resource"
 	"github.com/vite-cloud/vite/core/domain/runtime"
 )
 
+// Status is the state of a deployment.
+type Status string
+
+// Available deployment statuses.
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusRolledBack is the status of a failed deployment whose resources were removed.
+	StatusRolledBack Status = "rolled back"
+)
+
 // Deployment holds the information needed to deploy a service.
 // When updating fields, make sure to also update deploymentJSON accordingly.
 type Deployment struct {
@@ -24,9 +42,45 @@ type Deployment struct {
 	Docker  *runtime.Client
 	config  *config.Config
 	Locator *locator.Locator
+	Status  Status
+	// Failure describes why the deployment failed, it is nil if the deployment did not fail.
+	Failure *Failure
+	// CreatedAt is the time the deployment started, FinishedAt the time it ended.
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	// Trigger describes who started the deployment (e.g. cli:<user> or token:<label>).
+	Trigger string
+	// CommitMessage is the message of the commit the config was read from.
+	CommitMessage string
+	// Durations holds the time it took to deploy each service.
+	Durations map[string]time.Duration
 
 	Bus       chan<- Event
 	Resources sync.Map
+	// mu guards Add and Durations as services of a same layer are deployed concurrently.
+	mu sync.Mutex
+	// saveMu prevents an older snapshot of the manifest from overwriting a newer one,
+	// as it is saved by services deployed concurrently.
+	saveMu sync.Mutex
+	// lock is the deploy lock acquired by the caller of Deploy, if any, see WithLock.
+	lock *datadir.Lock
+}
+
+// Failure is the reason a deployment failed.
+type Failure struct {
+	// Service is the name of the service that failed, it is empty if the deployment
+	// failed before any service was deployed.
+	Service string
+	Reason  string
+}
+
+// Error implements the error interface.
+func (f *Failure) Error() string {
+	if f.Service == "" {
+		return f.Reason
+	}
+
+	return fmt.Sprintf("service %s failed: %s", f.Service, f.Reason)
 }
 
 func (d *Deployment) ID() string {
@@ -45,14 +99,20 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 		if err != nil {
 			return err
 		}
+		d.Add("subnet", service.Name, subnet.String())
 
 		events <- Event{
 			ID:      AcquireSubnet,
 			Service: service,
-			Data:    fmt.Sprintf("Assigned subnet %s to the service's network", subnet.String()),
+			Data:    SubnetPayload{Subnet: subnet.String()},
 		}
 
 		networkID, err := d.Docker.NetworkCreate(ctx, fmt.Sprintf("%s_%s", service.Name, d.ID()), runtime.NetworkCreateOptions{
+			Labels: map[string]string{
+				"cloud.vite.service":    service.Name,
+				"cloud.vite.deployment": d.ID(),
+				runtime.SubnetLabel:     subnet.String(),
+			},
 			IPAM: &network.IPAM{
 				Driver: "default",
 				Config: []network.IPAMConfig{
@@ -69,31 +129,34 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 		events <- Event{
 			ID:      CreateNetwork,
 			Service: service,
-			Data:    fmt.Sprintf("Created network %s", networkID),
+			Data:    NetworkPayload{Network: networkID},
 		}
 		d.Add("network", service.Name, networkID)
 
 		for _, require := range service.Requires {
-			id, err := d.Find("container", require.Name)
+			ids, err := d.ContainerIDs(require.Name)
 			if err != nil {
 				return err
 			}
 
-			if err = d.Docker.NetworkConnect(ctx, networkID, id.(string)); err != nil {
-				return err
+			for _, id := range ids {
+				if err = d.Docker.NetworkConnect(ctx, networkID, id); err != nil {
+					return err
+				}
 			}
 
 			events <- Event{
 				ID:      ConnectDependency,
 				Service: service,
-				Data:    fmt.Sprintf("Connected service %s to the service's network", require.Name),
+				Data:    DependencyPayload{Service: require.Name},
 			}
 
 		}
 	}
 
 	err := d.Docker.ImagePull(ctx, service.Image, runtime.ImagePullOptions{
-		Auth: service.Registry,
+		Auth:             service.Registry,
+		CredentialHelper: service.CredentialHelper,
 	})
 	if err != nil {
 		return err
@@ -102,7 +165,7 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 	events <- Event{
 		ID:      PullImage,
 		Service: service,
-		Data:    service.Image,
+		Data:    ImagePayload{Image: service.Image},
 	}
 
 	var networking *network.NetworkingConfig
@@ -121,15 +184,44 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 		}
 	}
 
+	mounts, err := d.mounts(ctx, events, service)
+	if err != nil {
+		return err
+	}
+
+	// Replicas are started one at a time and each must be running before the next one is started,
+	// so that the proxy can move traffic to the deployment replica by replica.
+	replicas := service.Replicas
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	for replica := 1; replica <= replicas; replica++ {
+		if err = d.deployReplica(ctx, events, service, replica, replicas, networking, mounts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deployReplica creates and starts one of the containers of a service, see ContainerName.
+// Once the container is running, it is recorded as ready and the manifest is saved for the proxy to route to it.
+func (d *Deployment) deployReplica(ctx context.Context, events chan<- Event, service *config.Service, replica, replicas int, networking *network.NetworkingConfig, mounts []mount.Mount) error {
 	ref, err := d.Docker.ContainerCreate(ctx, service.Image, runtime.ContainerCreateOptions{
-		Name:     fmt.Sprintf("%s_%s", d.ID(), service.Name),
+		Name:     d.ContainerName(service.Name, replica, replicas),
 		Env:      service.Env,
 		Registry: service.Registry,
 		Labels: map[string]string{
 			"cloud.vite.service":    service.Name,
 			"cloud.vite.deployment": fmt.Sprintf("%s", d.ID()),
+			"cloud.vite.replica":    strconv.Itoa(replica),
 		},
-		Networking: networking,
+		Networking:    networking,
+		Healthcheck:   healthConfig(service),
+		Resources:     service.Resources.DockerResources(),
+		RestartPolicy: service.Restart,
+		Mounts:        mounts,
 	})
 	if err != nil {
 		return err
@@ -138,6 +230,7 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 	events <- Event{
 		ID:      CreateContainer,
 		Service: service,
+		Data:    ContainerPayload{Container: ref.ID},
 	}
 	d.Add("created_containers", service.Name, ref)
 
@@ -149,7 +242,7 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 	events <- Event{
 		ID:      RunHook,
 		Service: service,
-		Data:    service.Hooks.Prestart,
+		Data:    HooksPayload{Commands: service.Hooks.Prestart},
 	}
 
 	err = d.Docker.ContainerStart(ctx, ref.ID)
@@ -160,6 +253,7 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 	events <- Event{
 		ID:      StartContainer,
 		Service: service,
+		Data:    ContainerPayload{Container: ref.ID},
 	}
 
 	err = d.RunHooks(ctx, ref.ID, service.Hooks.Poststart)
@@ -170,7 +264,7 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 	events <- Event{
 		ID:      RunHook,
 		Service: service,
-		Data:    service.Hooks.Poststart,
+		Data:    HooksPayload{Commands: service.Hooks.Poststart},
 	}
 
 	err = d.EnsureContainerIsRunning(ctx, ref.ID)
@@ -181,35 +275,95 @@ func (d *Deployment) Deploy(ctx context.Context, events chan<- Event, service *c
 		return err
 	}
 
+	d.Add("ready_containers", service.Name, ref.ID)
+
+	if err = d.save(); err != nil {
+		return err
+	}
+
+	events <- Event{
+		ID:      ReplicaReady,
+		Service: service,
+		Data:    ReplicaPayload{Replica: replica, Replicas: replicas, Container: ref.ID},
+	}
+
 	return nil
 }
 
+// ContainerName returns the name of the container running the given replica of a service, starting at 1.
+// Services with a single replica keep the name they had before replicas were supported.
+func (d *Deployment) ContainerName(service string, replica, replicas int) string {
+	if replicas <= 1 {
+		return fmt.Sprintf("%s_%s", d.ID(), service)
+	}
+
+	return fmt.Sprintf("%s_%s_%d", d.ID(), service, replica)
+}
+
 var (
 	ErrContainerNotRunning = errors.New("container is not running")
 	ErrContainerTimeout    = errors.New("container is not running (timeout)")
 )
 
+// ContainerLogLines is the number of log lines attached to a ContainerError.
+const ContainerLogLines = 20
+
+// ContainerError is returned when a container fails to start.
+// It holds the state of the container and its last logs to help understand why.
+type ContainerError struct {
+	// Err is either ErrContainerNotRunning or ErrContainerTimeout.
+	Err       error
+	ExitCode  int
+	OOMKilled bool
+	Logs      []string
+}
+
+func (e *ContainerError) Error() string {
+	if e.OOMKilled {
+		return fmt.Sprintf("%s (exit code %d, out of memory)", e.Err, e.ExitCode)
+	}
+
+	return fmt.Sprintf("%s (exit code %d)", e.Err, e.ExitCode)
+}
+
+func (e *ContainerError) Unwrap() error {
+	return e.Err
+}
+
+// containerError returns a ContainerError describing the state of a container that failed to start.
+func (d *Deployment) containerError(containerID string, err error) error {
+	// The context passed to EnsureContainerIsRunning may have expired already.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	containerErr := &ContainerError{Err: err}
+
+	info, inspectErr := d.Docker.ContainerInspect(ctx, containerID)
+	if inspectErr != nil {
+		return err
+	}
+
+	containerErr.ExitCode = info.State.ExitCode
+	containerErr.OOMKilled = info.State.OOMKilled
+
+	containerErr.Logs, _ = d.Docker.ContainerLogs(ctx, containerID, ContainerLogLines)
+
+	return containerErr
+}
+
 // EnsureContainerIsRunning will wait for the container to start and then return
 // an error if the container is not running after either :
 // - 10 seconds if the container has no health-check
-// - Retries * (Interval + Timeout) if the container has a health-check
-//
-// todo(pipeline): return logs from failed container
+// - StartPeriod + Retries * (Interval + Timeout) if the container has a health-check,
+// using Docker's defaults for the values that are not set.
+// The returned error is a *ContainerError holding the container's last logs if it did not start.
 func (d *Deployment) EnsureContainerIsRunning(ctx context.Context, containerID string) error {
 	info, err := d.Docker.ContainerInspect(ctx, containerID)
 	if err != nil {
 		return err
 	}
 
-	var timeout time.Duration
-
-	if info.Config.Healthcheck == nil {
-		timeout = 10 * time.Second
-	} else {
-		seconds := float64(info.Config.Healthcheck.Retries) * (info.Config.Healthcheck.Interval + info.Config.Healthcheck.Timeout).Seconds()
-
-		timeout = time.Duration(seconds) * time.Second
-	}
+	timeout := healthcheckTimeout(info.Config.Healthcheck)
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -217,7 +371,7 @@ func (d *Deployment) EnsureContainerIsRunning(ctx context.Context, containerID s
 	for {
 		select {
 		case <-ctx.Done():
-			return ErrContainerTimeout
+			return d.containerError(containerID, ErrContainerTimeout)
 		case <-time.After(250 * time.Millisecond):
 			info, err = d.Docker.ContainerInspect(ctx, containerID)
 			if err != nil {
@@ -225,7 +379,7 @@ func (d *Deployment) EnsureContainerIsRunning(ctx context.Context, containerID s
 			}
 
 			if info.RestartCount > 0 || info.State.ExitCode != 0 {
-				return ErrContainerNotRunning
+				return d.containerError(containerID, ErrContainerNotRunning)
 			}
 
 			hasHealthchecks := info.State.Health != nil
@@ -235,7 +389,7 @@ func (d *Deployment) EnsureContainerIsRunning(ctx context.Context, containerID s
 					return nil
 				}
 
-				return ErrContainerNotRunning
+				return d.containerError(containerID, ErrContainerNotRunning)
 			}
 
 			if info.State.Health.Status == types.Healthy {
@@ -243,12 +397,50 @@ func (d *Deployment) EnsureContainerIsRunning(ctx context.Context, containerID s
 			}
 
 			if info.State.Health.Status == types.Unhealthy {
-				return ErrContainerNotRunning
+				return d.containerError(containerID, ErrContainerNotRunning)
 			}
 		}
 	}
 }
 
+// Docker's defaults for the healthcheck settings left empty.
+const (
+	defaultHealthcheckInterval = 30 * time.Second
+	defaultHealthcheckTimeout  = 30 * time.Second
+	defaultHealthcheckRetries  = 3
+)
+
+// healthcheckTimeout returns the time after which a container is considered not to be running.
+func healthcheckTimeout(healthcheck *container.HealthConfig) time.Duration {
+	if healthcheck == nil || len(healthcheck.Test) == 0 || healthcheck.Test[0] == "NONE" {
+		return 10 * time.Second
+	}
+
+	interval, timeout, retries := healthcheck.Interval, healthcheck.Timeout, healthcheck.Retries
+	if interval == 0 {
+		interval = defaultHealthcheckInterval
+	}
+
+	if timeout == 0 {
+		timeout = defaultHealthcheckTimeout
+	}
+
+	if retries == 0 {
+		retries = defaultHealthcheckRetries
+	}
+
+	return healthcheck.StartPeriod + time.Duration(retries)*(interval+timeout)
+}
+
+// healthConfig returns the healthcheck configured for the service, if any.
+func healthConfig(service *config.Service) *container.HealthConfig {
+	if service.Healthcheck == nil {
+		return nil
+	}
+
+	return service.Healthcheck.HealthConfig()
+}
+
 type LabeledValue struct {
 	Label string
 	Value any
@@ -258,13 +450,23 @@ var ErrValueNotFound = errors.New("value not found")
 
 // deploymentJSON is the marshalable representation of a Manifest as it does not rely on sync.Map.
 type deploymentJSON struct {
-	ID        string
-	Resources map[string][]LabeledValue
-	Locator   *locator.Locator
+	ID            string
+	Resources     map[string][]LabeledValue
+	Locator       *locator.Locator
+	Status        Status
+	Failure       *Failure `json:",omitempty"`
+	CreatedAt     time.Time
+	FinishedAt    time.Time
+	Trigger       string
+	CommitMessage string
+	Durations     map[string]time.Duration `json:",omitempty"`
 }
 
 // Add adds a resource to the manifest under a given tag.
 func (d *Deployment) Add(key, label string, value any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	v, ok := d.Resources.Load(key)
 	if !ok {
 		d.Resources.Store(key, []LabeledValue{{label, value}})
@@ -288,9 +490,16 @@ func (d *Deployment) Get(key string) ([]LabeledValue, error) {
 // It takes care of converting the resource map to a marshalable map.
 func (d *Deployment) MarshalJSON() ([]byte, error) {
 	return json.Marshal(deploymentJSON{
-		ID:        d.ID(),
-		Resources: d.All(),
-		Locator:   d.Locator,
+		ID:            d.ID(),
+		Resources:     d.All(),
+		Locator:       d.Locator,
+		Status:        d.Status,
+		Failure:       d.Failure,
+		CreatedAt:     d.CreatedAt,
+		FinishedAt:    d.FinishedAt,
+		Trigger:       d.Trigger,
+		CommitMessage: d.CommitMessage,
+		Durations:     d.durations(),
 	})
 }
 
@@ -308,6 +517,13 @@ func (d *Deployment) UnmarshalJSON(data []byte) error {
 
 	d.id = manifestJSON.ID
 	d.Locator = manifestJSON.Locator
+	d.Status = manifestJSON.Status
+	d.Failure = manifestJSON.Failure
+	d.CreatedAt = manifestJSON.CreatedAt
+	d.FinishedAt = manifestJSON.FinishedAt
+	d.Trigger = manifestJSON.Trigger
+	d.CommitMessage = manifestJSON.CommitMessage
+	d.Durations = manifestJSON.Durations
 
 	for k, v := range manifestJSON.Resources {
 		d.Resources.Store(k, v)
@@ -331,6 +547,75 @@ func (d *Deployment) Find(key, label string) (any, error) {
 	return nil, ErrValueNotFound
 }
 
+// ContainerID returns the id of the first container created for the given service.
+func (d *Deployment) ContainerID(service string) (string, error) {
+	ids, err := d.ContainerIDs(service)
+	if err != nil {
+		return "", err
+	}
+
+	return ids[0], nil
+}
+
+// ContainerIDs returns the ids of the containers created for the given service, one per replica.
+func (d *Deployment) ContainerIDs(service string) ([]string, error) {
+	return d.containerIDs("created_containers", service)
+}
+
+// ReadyContainerIDs returns the ids of the containers of the given service that are running,
+// in the order they were started. It is empty if no replica of the service is running yet.
+func (d *Deployment) ReadyContainerIDs(service string) []string {
+	ids, _ := d.containerIDs("ready_containers", service)
+
+	return ids
+}
+
+// containerIDs returns the ids of the containers stored under the given key for the given service.
+func (d *Deployment) containerIDs(key, service string) ([]string, error) {
+	values, err := d.Get(key)
+	if err != nil {
+		return nil, ErrValueNotFound
+	}
+
+	var ids []string
+
+	for _, value := range values {
+		if value.Label != service {
+			continue
+		}
+
+		id, err := containerID(value.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w for service %s", err, service)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrValueNotFound
+	}
+
+	return ids, nil
+}
+
+// containerID returns the id of a container stored as a resource.
+func containerID(v any) (string, error) {
+	switch ref := v.(type) {
+	case container.ContainerCreateCreatedBody:
+		return ref.ID, nil
+	case map[string]any:
+		// resources loaded from a manifest are decoded as generic maps.
+		if id, ok := ref["Id"].(string); ok {
+			return id, nil
+		}
+	case string:
+		return ref, nil
+	}
+
+	return "", fmt.Errorf("invalid container reference %v", v)
+}
+
 func (d *Deployment) All() map[string][]LabeledValue {
 	v := make(map[string][]LabeledValue)
 
@@ -344,15 +629,118 @@ func (d *Deployment) All() map[string][]LabeledValue {
 	return v
 }
 
+// Summary returns the status of the deployment, who triggered it and the commit message.
+func (d *Deployment) Summary() string {
+	status := string(d.Status)
+	if status == "" {
+		status = "unknown"
+	} else if d.Failure != nil && d.Failure.Service != "" {
+		status = fmt.Sprintf("%s (%s)", status, d.Failure.Service)
+	}
+
+	return fmt.Sprintf("%s | %s | %s", status, orNone(d.Trigger), orNone(d.CommitMessage))
+}
+
+// Describe writes a human-readable description of the deployment.
+func (d *Deployment) Describe(w io.Writer) {
+	fmt.Fprintf(w, "ID:       %s\n", d.ID())
+	fmt.Fprintf(w, "Status:   %s\n", orNone(string(d.Status)))
+
+	if d.Failure != nil {
+		fmt.Fprintf(w, "Failure:  %s\n", d.Failure)
+	}
+
+	fmt.Fprintf(w, "Created:  %s\n", d.Time().Format(time.RFC1123))
+
+	if !d.FinishedAt.IsZero() {
+		fmt.Fprintf(w, "Finished: %s (took %s)\n", d.FinishedAt.Format(time.RFC1123), d.FinishedAt.Sub(d.Time()).Round(time.Millisecond))
+	}
+
+	fmt.Fprintf(w, "Trigger:  %s\n", orNone(d.Trigger))
+
+	if d.Locator != nil {
+		fmt.Fprintf(w, "Commit:   %s %s\n", d.Locator.Commit, d.CommitMessage)
+	}
+
+	if len(d.Durations) == 0 {
+		return
+	}
+
+	services := make([]string, 0, len(d.Durations))
+	for service := range d.Durations {
+		services = append(services, service)
+	}
+
+	sort.Strings(services)
+
+	fmt.Fprintln(w, "Services:")
+
+	for _, service := range services {
+		fmt.Fprintf(w, "- %s: %s\n", service, d.Durations[service].Round(time.Millisecond))
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
+
 // Time returns the time the deployment was created.
-// todo: Add a Time property on the deployment manifest rather than using the deployment id that
-// todo: happens to be the creation time.
+// Manifests created before CreatedAt was recorded fall back to the deployment id,
+// which happens to be the creation time.
 func (d *Deployment) Time() time.Time {
+	if !d.CreatedAt.IsZero() {
+		return d.CreatedAt
+	}
+
 	id, _ := strconv.ParseInt(d.ID(), 10, 64)
 
 	return time.Unix(0, id)
 }
 
+// setDuration records the time it took to deploy the given service.
+func (d *Deployment) setDuration(service string, duration time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.Durations == nil {
+		d.Durations = make(map[string]time.Duration)
+	}
+
+	d.Durations[service] = duration
+}
+
+// durations returns a copy of the durations safe to use while services are still being deployed.
+func (d *Deployment) durations() map[string]time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.Durations == nil {
+		return nil
+	}
+
+	durations := make(map[string]time.Duration, len(d.Durations))
+	for service, duration := range d.Durations {
+		durations[service] = duration
+	}
+
+	return durations
+}
+
+// save writes the manifest of the deployment to the store.
+// It is saved whenever a replica is ready so that the proxy can route to it before the deployment finishes.
+func (d *Deployment) save() error {
+	d.saveMu.Lock()
+	defer d.saveMu.Unlock()
+
+	return resource.Save[*Deployment](Store, d, func(d *Deployment) string {
+		return d.ID()
+	})
+}
+
 func (d *Deployment) RunHooks(ctx context.Context, containerID string, commands []string) error {
 	for _, command := range commands {
 		err := d.Docker.ContainerExec(ctx, containerID, command)
@@ -2,8 +2,13 @@ package deployment
 
 import (
 	"encoding/json"
+	"github.com/docker/docker/api/types/container"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/resource"
 	"gotest.tools/v3/assert"
+	"strconv"
 	"testing"
+	"time"
 )
 
 //func TestGet(t *testing.T) {
@@ -254,3 +259,255 @@ func TestDeployment_Get2(t *testing.T) {
 	assert.ErrorContains(t, err, "no resources found matching given key")
 
 }
+
+func TestLatest(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	for id, status := range map[string]Status{"1": StatusSucceeded, "2": StatusSucceeded, "3": StatusFailed} {
+		err := resource.Save[*Deployment](Store, &Deployment{id: id, Status: status}, func(d *Deployment) string {
+			return d.ID()
+		})
+		assert.NilError(t, err)
+	}
+
+	latest, err := Latest()
+	assert.NilError(t, err)
+	assert.Equal(t, latest.ID(), "2")
+}
+
+func TestLatest2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	_, err := Latest()
+	assert.ErrorIs(t, err, ErrNoDeployment)
+}
+
+func TestLockDeployments(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	lock, err := LockDeployments()
+	assert.NilError(t, err)
+
+	_, err = LockDeployments()
+	assert.ErrorIs(t, err, ErrDeploymentInProgress)
+
+	assert.NilError(t, lock.Unlock())
+
+	lock, err = LockDeployments()
+	assert.NilError(t, err)
+	assert.NilError(t, lock.Unlock())
+}
+
+func TestDeployment_UnmarshalJSON3(t *testing.T) {
+	d := &Deployment{id: "1", Status: StatusFailed, Failure: &Failure{Service: "api", Reason: "container is not running"}}
+
+	marshaled, err := json.Marshal(d)
+	assert.NilError(t, err)
+
+	var unmarshaled Deployment
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.NilError(t, err)
+
+	assert.Equal(t, unmarshaled.Status, StatusFailed)
+	assert.DeepEqual(t, unmarshaled.Failure, d.Failure)
+}
+
+func TestDeployment_Summary(t *testing.T) {
+	tests := []struct {
+		deployment *Deployment
+		want       string
+	}{
+		{&Deployment{}, "unknown | none | none"},
+		{&Deployment{Status: StatusSucceeded, Trigger: "cli:root", CommitMessage: "fix login"}, "succeeded | cli:root | fix login"},
+		{&Deployment{Status: StatusFailed, Failure: &Failure{Reason: "invalid config"}}, "failed | none | none"},
+		{&Deployment{Status: StatusRolledBack, Failure: &Failure{Service: "api", Reason: "container is not running"}}, "rolled back (api) | none | none"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.deployment.Summary(), test.want)
+	}
+}
+
+func TestDeployment_Time(t *testing.T) {
+	createdAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	d := &Deployment{id: "1", CreatedAt: createdAt}
+	assert.Assert(t, d.Time().Equal(createdAt))
+
+	// manifests without a creation time fall back to the id
+	d = &Deployment{id: strconv.FormatInt(createdAt.UnixNano(), 10)}
+	assert.Assert(t, d.Time().Equal(createdAt))
+}
+
+func TestDeployment_UnmarshalJSON4(t *testing.T) {
+	d := &Deployment{
+		id:            "1",
+		CreatedAt:     time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC),
+		FinishedAt:    time.Date(2022, 6, 1, 12, 1, 0, 0, time.UTC),
+		Trigger:       "token:ci",
+		CommitMessage: "fix login",
+		Durations:     map[string]time.Duration{"api": 3 * time.Second},
+	}
+
+	marshaled, err := json.Marshal(d)
+	assert.NilError(t, err)
+
+	var unmarshaled Deployment
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.NilError(t, err)
+
+	assert.Assert(t, unmarshaled.CreatedAt.Equal(d.CreatedAt))
+	assert.Assert(t, unmarshaled.FinishedAt.Equal(d.FinishedAt))
+	assert.Equal(t, unmarshaled.Trigger, "token:ci")
+	assert.Equal(t, unmarshaled.CommitMessage, "fix login")
+	assert.DeepEqual(t, unmarshaled.Durations, d.Durations)
+}
+
+func TestHealthcheckTimeout(t *testing.T) {
+	assert.Equal(t, healthcheckTimeout(nil), 10*time.Second)
+	assert.Equal(t, healthcheckTimeout(&container.HealthConfig{Test: []string{"NONE"}}), 10*time.Second)
+
+	// docker's defaults are used for empty settings
+	assert.Equal(t, healthcheckTimeout(&container.HealthConfig{Test: []string{"CMD-SHELL", "true"}}), 180*time.Second)
+
+	assert.Equal(t, healthcheckTimeout(&container.HealthConfig{
+		Test:        []string{"CMD-SHELL", "true"},
+		Interval:    5 * time.Second,
+		Timeout:     time.Second,
+		Retries:     2,
+		StartPeriod: 10 * time.Second,
+	}), 22*time.Second)
+}
+
+func TestDeployment_ContainerIDs(t *testing.T) {
+	d := &Deployment{id: "1"}
+
+	_, err := d.ContainerIDs("api")
+	assert.ErrorIs(t, err, ErrValueNotFound)
+
+	d.Add("created_containers", "api", container.ContainerCreateCreatedBody{ID: "a1"})
+	d.Add("created_containers", "db", container.ContainerCreateCreatedBody{ID: "d1"})
+	d.Add("created_containers", "api", container.ContainerCreateCreatedBody{ID: "a2"})
+	d.Add("ready_containers", "api", "a1")
+
+	ids, err := d.ContainerIDs("api")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []string{"a1", "a2"})
+
+	id, err := d.ContainerID("api")
+	assert.NilError(t, err)
+	assert.Equal(t, id, "a1")
+
+	assert.DeepEqual(t, d.ReadyContainerIDs("api"), []string{"a1"})
+	assert.Equal(t, len(d.ReadyContainerIDs("db")), 0)
+
+	// replicas are still found once the manifest is loaded again
+	marshaled, err := json.Marshal(d)
+	assert.NilError(t, err)
+
+	var unmarshaled Deployment
+	assert.NilError(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	ids, err = unmarshaled.ContainerIDs("api")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, []string{"a1", "a2"})
+	assert.DeepEqual(t, unmarshaled.ReadyContainerIDs("api"), []string{"a1"})
+}
+
+func TestDeployment_ContainerName(t *testing.T) {
+	d := &Deployment{id: "1"}
+
+	assert.Equal(t, d.ContainerName("api", 1, 1), "1_api")
+	assert.Equal(t, d.ContainerName("api", 1, 3), "1_api_1")
+	assert.Equal(t, d.ContainerName("api", 3, 3), "1_api_3")
+}
+
+func TestInProgress(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	_, err := InProgress()
+	assert.ErrorIs(t, err, ErrNoDeploymentInProgress)
+
+	for id, status := range map[string]Status{"1": StatusRunning, "2": StatusRunning, "3": StatusSucceeded} {
+		err = resource.Save[*Deployment](Store, &Deployment{id: id, Status: status}, func(d *Deployment) string {
+			return d.ID()
+		})
+		assert.NilError(t, err)
+	}
+
+	// deployments killed while running are not in progress
+	_, err = InProgress()
+	assert.ErrorIs(t, err, ErrNoDeploymentInProgress)
+
+	lock, err := LockDeployments()
+	assert.NilError(t, err)
+	defer lock.Unlock()
+
+	dep, err := InProgress()
+	assert.NilError(t, err)
+	assert.Equal(t, dep.ID(), "2")
+}
+
+func TestDeployment_InUse(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	d := &Deployment{id: "1"}
+
+	inUse, err := d.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, !inUse)
+
+	served, err := d.Serve()
+	assert.NilError(t, err)
+
+	inUse, err = d.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, inUse)
+
+	// other deployments are not in use
+	inUse, err = (&Deployment{id: "2"}).InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, !inUse)
+
+	assert.NilError(t, served.Unlock())
+
+	inUse, err = d.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, !inUse)
+}
+
+func TestDeployment_ServeInProgress(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	d := &Deployment{id: "1", Status: StatusRunning}
+	assert.NilError(t, d.save())
+
+	served, err := d.ServeInProgress()
+	assert.NilError(t, err)
+
+	// the deployment fails, its replicas are only removed once the proxy released them
+	d.Status = StatusFailed
+	assert.NilError(t, d.save())
+
+	released := make(chan error)
+	go func() {
+		released <- d.waitUnserved()
+	}()
+
+	select {
+	case <-released:
+		t.Fatal("replicas released while served")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NilError(t, served.Unlock())
+	assert.NilError(t, <-released)
+
+	// a failed deployment is no longer served
+	_, err = d.ServeInProgress()
+	assert.ErrorIs(t, err, ErrNoDeploymentInProgress)
+
+	inUse, err := d.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, !inUse)
+}
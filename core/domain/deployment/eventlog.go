@@ -0,0 +1,127 @@
+package deployment
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/log"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoEventLog is returned when a deployment has no event log.
+var ErrNoEventLog = errors.New("no event log found for deployment")
+
+// EventLogPath returns the path to the event log of the given deployment.
+func EventLogPath(id string) (string, error) {
+	dir, err := Store.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, id+".log"), nil
+}
+
+// ParseEvent parses a line of an event log.
+func ParseEvent(line string) (Event, error) {
+	var event Event
+
+	err := json.Unmarshal([]byte(line), &event)
+
+	return event, err
+}
+
+// ReadEvents returns the events logged by the given deployment.
+func ReadEvents(id string) ([]Event, error) {
+	path, err := EventLogPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w %s", ErrNoEventLog, id)
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var events []Event
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event, err := ParseEvent(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+// record timestamps the events sent to the returned channel, writes them to the event log
+// of the given deployment and forwards them to out. The returned channel must be closed once
+// all the events have been sent, the returned function then waits for them to be forwarded.
+func record(out chan<- Event, id string) (chan<- Event, func()) {
+	in := make(chan Event)
+	done := make(chan struct{})
+
+	path, err := EventLogPath(id)
+
+	var file *os.File
+	if err == nil {
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	}
+
+	// The deployment must not fail because its events can not be persisted.
+	if err != nil {
+		log.Log(zoup.WarnLevel, "could not open event log", zoup.Fields{
+			"deployment": id,
+			"err":        err,
+		})
+	}
+
+	go func() {
+		defer close(done)
+
+		for event := range in {
+			event.Time = time.Now()
+
+			if file != nil {
+				writeEvent(file, id, event)
+			}
+
+			out <- event
+		}
+
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	return in, func() {
+		<-done
+	}
+}
+
+// writeEvent appends an event to the event log.
+func writeEvent(w io.Writer, id string, event Event) {
+	contents, err := json.Marshal(event)
+	if err == nil {
+		_, err = w.Write(append(contents, '\n'))
+	}
+
+	if err != nil {
+		log.Log(zoup.WarnLevel, "could not write to event log", zoup.Fields{
+			"deployment": id,
+			"err":        err,
+		})
+	}
+}
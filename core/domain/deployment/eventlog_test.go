@@ -0,0 +1,50 @@
+package deployment
+
+import (
+	"errors"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/log"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	log.SetLogger(&zoup.MemoryWriter{})
+	datadir.UseTestHome(t)
+
+	out := make(chan Event, 3)
+
+	in, wait := record(out, "1")
+	in <- Event{ID: StartEvent, Data: DeploymentPayload{Deployment: "1"}}
+	in <- Event{ID: ErrorEvent, Service: &config.Service{Name: "api"}, Data: NewErrorPayload(errors.New("container is not running"))}
+	in <- Event{ID: FinishEvent}
+	close(in)
+	wait()
+
+	assert.Equal(t, len(out), 3)
+
+	events, err := ReadEvents("1")
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 3)
+
+	assert.Equal(t, events[0].ID, StartEvent)
+	assert.Equal(t, events[0].Label(), "global")
+	assert.Equal(t, events[0].Data, DeploymentPayload{Deployment: "1"})
+	assert.Assert(t, !events[0].Time.IsZero())
+
+	assert.Equal(t, events[1].ID, ErrorEvent)
+	assert.Equal(t, events[1].Label(), "api")
+	assert.DeepEqual(t, events[1].Data, ErrorPayload{Message: "container is not running"})
+
+	assert.Equal(t, events[2].ID, FinishEvent)
+	assert.Equal(t, events[2].Data, Payload(nil))
+}
+
+func TestReadEvents(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	_, err := ReadEvents("1")
+	assert.ErrorIs(t, err, ErrNoEventLog)
+}
@@ -2,6 +2,8 @@ package deployment
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/vite-cloud/vite/core/domain/locator"
 	"github.com/vite-cloud/vite/core/domain/resource"
 	"strconv"
@@ -11,6 +13,7 @@ import (
 	"github.com/vite-cloud/vite/core/domain/config"
 	"github.com/vite-cloud/vite/core/domain/datadir"
 	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/domain/secret"
 )
 
 const (
@@ -24,53 +27,286 @@ const (
 	ConnectDependency    = "ConnectDependency"
 	AcquireSubnet        = "AcquireSubnet"
 	CreateNetwork        = "CreateNetwork"
+	RollbackDeployment   = "RollbackDeployment"
+	ReplicaReady         = "ReplicaReady"
 )
 
 const Store = datadir.Store("deployments")
 
-func Deploy(events chan<- Event, locator *locator.Locator) {
-	err := deploy(events, locator)
+// ErrDeploymentInProgress is returned when a deployment is started while another one is running.
+var ErrDeploymentInProgress = errors.New("another deployment is in progress, wait for it to finish")
+
+// DeployLock is the name of the lock held while a deployment is running.
+const DeployLock = "deploy"
+
+// ErrDeploymentNotSucceeded is returned when trying to serve a deployment that did not succeed.
+var ErrDeploymentNotSucceeded = errors.New("only succeeded deployments can be served")
+
+// ErrNoDeployment is returned when no deployment succeeded yet.
+var ErrNoDeployment = errors.New("no successful deployment found, run `vite deploy` first")
+
+// ErrNoDeploymentInProgress is returned when no deployment is running.
+var ErrNoDeploymentInProgress = errors.New("no deployment is in progress")
+
+// Latest returns the most recent deployment that succeeded.
+func Latest() (*Deployment, error) {
+	deployments, err := resource.List[Deployment](Store)
 	if err != nil {
-		events <- Event{
-			ID:   ErrorEvent,
-			Data: err,
+		return nil, err
+	}
+
+	var latest *Deployment
+
+	for _, d := range deployments {
+		if d.Status != StatusSucceeded {
+			continue
 		}
-	} else {
-		events <- Event{
-			ID: FinishEvent,
+
+		if latest == nil || d.Time().After(latest.Time()) {
+			latest = d
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrNoDeployment
+	}
+
+	return latest, nil
+}
+
+// Option configures a deployment before it starts.
+type Option func(*Deployment)
+
+// WithLock deploys while holding the given deploy lock, acquired by the caller using WaitForDeployments.
+// The lock is released once the deployment is over.
+func WithLock(lock *datadir.Lock) Option {
+	return func(d *Deployment) {
+		d.lock = lock
+	}
+}
+
+// WithTrigger records who triggered the deployment.
+func WithTrigger(trigger string) Option {
+	return func(d *Deployment) {
+		d.Trigger = trigger
+	}
+}
+
+// Deploy deploys the services of the config located by the given locator.
+// Events are written to the deployment's event log as they are sent to the channel.
+// A FinishEvent is always sent last and the channel is then closed.
+func Deploy(events chan<- Event, locator *locator.Locator, opts ...Option) {
+	defer close(events)
+
+	now := time.Now()
+
+	depl := &Deployment{
+		id:        strconv.FormatInt(now.UnixNano(), 10),
+		Locator:   locator,
+		Status:    StatusRunning,
+		CreatedAt: now,
+	}
+
+	for _, opt := range opts {
+		opt(depl)
+	}
+
+	lock := depl.lock
+	if lock == nil {
+		var err error
+		if lock, err = LockDeployments(); err != nil {
+			events <- Event{
+				ID:   ErrorEvent,
+				Data: NewErrorPayload(err),
+				Time: time.Now(),
+			}
+			events <- Event{
+				ID:   FinishEvent,
+				Time: time.Now(),
+			}
+			return
+		}
+	}
+	defer lock.Unlock()
+
+	logged, wait := record(events, depl.ID())
+	defer wait()
+	defer close(logged)
+
+	depl.Bus = logged
+
+	if err := deploy(logged, depl); err != nil {
+		logged <- Event{
+			ID:   ErrorEvent,
+			Data: NewErrorPayload(err),
 		}
 	}
+
+	logged <- Event{
+		ID: FinishEvent,
+	}
 }
 
-func deploy(events chan<- Event, locator *locator.Locator) error {
-	docker, err := runtime.NewClient()
+// InProgress returns the most recent deployment that is still running, if any.
+// Its replicas are routed to by the proxy as soon as they are ready, see Deployment.ReadyContainerIDs.
+// Deployments whose process was killed are not in progress, see Deployment.IsRunning.
+func InProgress() (*Deployment, error) {
+	deployments, err := resource.List[Deployment](Store)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	depl := Deployment{
-		id:      strconv.FormatInt(time.Now().UnixNano(), 10),
-		Docker:  docker,
-		Bus:     events,
-		Locator: locator,
+	var latest *Deployment
+
+	for _, d := range deployments {
+		if d.Status != StatusRunning {
+			continue
+		}
+
+		if latest == nil || d.Time().After(latest.Time()) {
+			latest = d
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrNoDeploymentInProgress
+	}
+
+	if running, err := latest.IsRunning(); err != nil {
+		return nil, err
+	} else if !running {
+		return nil, ErrNoDeploymentInProgress
+	}
+
+	return latest, nil
+}
+
+// IsRunning returns true if the deployment is still in progress.
+// Deployments whose process was killed keep their running status, so it must also hold the deploy lock.
+func (d *Deployment) IsRunning() (bool, error) {
+	if d.Status != StatusRunning {
+		return false, nil
+	}
+
+	return IsDeploying()
+}
+
+// IsDeploying returns true if a deployment is running, that is if the deploy lock is held.
+func IsDeploying() (bool, error) {
+	lock, err := Store.TryLock(DeployLock)
+	if errors.Is(err, datadir.ErrLocked) {
+		return true, nil
+	} else if err != nil {
+		return false, err
 	}
+
+	return false, lock.Unlock()
+}
+
+// WaitForDeployments waits for the deployment in progress, if any, to finish and prevents other processes
+// from deploying until the returned lock is released.
+func WaitForDeployments() (*datadir.Lock, error) {
+	return Store.Lock(DeployLock)
+}
+
+// LockDeployments prevents other processes from deploying until the returned lock is released.
+// It returns ErrDeploymentInProgress if a deployment is already running.
+func LockDeployments() (*datadir.Lock, error) {
+	lock, err := Store.TryLock(DeployLock)
+	if errors.Is(err, datadir.ErrLocked) {
+		return nil, ErrDeploymentInProgress
+	}
+
+	return lock, err
+}
+
+func deploy(events chan<- Event, depl *Deployment) (err error) {
+	var conf *config.Config
+
 	defer func(depl *Deployment) {
-		err = resource.Save[*Deployment](Store, depl, func(d *Deployment) string {
-			return d.ID()
-		})
+		// The proxy only ever routes traffic to succeeded deployments,
+		// so the status must be set before the manifest is saved.
+		if err != nil {
+			depl.Status = StatusFailed
+
+			if !errors.As(err, &depl.Failure) {
+				depl.Failure = &Failure{Reason: err.Error()}
+			}
+
+			// The failure is saved in the manifest and shown by `vite deployments show`.
+			redactor := config.NewRedactor()
+			if conf != nil {
+				redactor = conf.Redactor()
+			}
+			depl.Failure.Reason = redactor.String(depl.Failure.Reason)
+
+			// Once the failure is saved, the proxy stops routing to the ready replicas of the deployment,
+			// they are only removed after it drained the requests it was serving them.
+			if err := depl.save(); err != nil {
+				events <- Event{
+					ID:   ErrorEvent,
+					Data: NewErrorPayload(err),
+				}
+			} else if err := depl.waitUnserved(); err != nil {
+				events <- Event{
+					ID:   ErrorEvent,
+					Data: NewErrorPayload(err),
+				}
+			}
+
+			if depl.rollback(events) {
+				depl.Status = StatusRolledBack
+			}
+		} else {
+			depl.Status = StatusSucceeded
+		}
+
+		depl.FinishedAt = time.Now()
+
+		err := depl.save()
 		if err != nil {
 			events <- Event{
 				ID:   ErrorEvent,
-				Data: err,
+				Data: NewErrorPayload(err),
 			}
+			return
+		}
+
+		if depl.Status != StatusSucceeded {
+			return
 		}
-	}(&depl)
+
+		err = Prune(context.Background(), events, conf.Retention, depl)
+		if err != nil {
+			events <- Event{
+				ID:   ErrorEvent,
+				Data: NewErrorPayload(err),
+			}
+		}
+	}(depl)
 
 	events <- Event{
 		ID:   StartEvent,
-		Data: depl.ID(),
+		Data: DeploymentPayload{Deployment: depl.ID()},
+	}
+
+	depl.Docker, err = runtime.NewClient()
+	if err != nil {
+		return err
+	}
+
+	conf, err = config.Get(depl.Locator)
+	if err != nil {
+		return err
+	}
+
+	// Secrets are only resolved now so that they never end up in the cached config.
+	conf, err = conf.Expand(secret.Expand)
+	if err != nil {
+		return err
 	}
-	conf, err := config.Get(locator)
+
+	depl.CommitMessage, err = depl.Locator.CommitMessage()
 	if err != nil {
 		return err
 	}
@@ -80,17 +316,17 @@ func deploy(events chan<- Event, locator *locator.Locator) error {
 		return err
 	}
 
-	errored := false
+	var (
+		mu      sync.Mutex
+		failure *Failure
+	)
 
 	for i, layer := range layers {
 		var wg sync.WaitGroup
 
 		events <- Event{
-			ID: StartLayerDeployment,
-			Data: struct {
-				Current int
-				Total   int
-			}{i + 1, len(layers)},
+			ID:   StartLayerDeployment,
+			Data: LayerPayload{Current: i + 1, Total: len(layers)},
 		}
 
 		for _, s := range layer {
@@ -98,14 +334,22 @@ func deploy(events chan<- Event, locator *locator.Locator) error {
 			go func(s *config.Service) {
 				defer wg.Done()
 
-				err = depl.Deploy(context.Background(), events, s)
+				start := time.Now()
+
+				err := depl.Deploy(context.Background(), events, s)
+				depl.setDuration(s.Name, time.Since(start))
 				if err != nil {
 					events <- Event{
 						ID:      ErrorEvent,
 						Service: s,
-						Data:    err,
+						Data:    NewErrorPayload(err),
+					}
+
+					mu.Lock()
+					if failure == nil {
+						failure = &Failure{Service: s.Name, Reason: err.Error()}
 					}
-					errored = true
+					mu.Unlock()
 					return
 				}
 
@@ -118,10 +362,30 @@ func deploy(events chan<- Event, locator *locator.Locator) error {
 
 		wg.Wait()
 
-		if errored {
-			break
+		if failure != nil {
+			return failure
 		}
 	}
 
 	return nil
 }
+
+// rollback tears down everything created by a failed deployment, in the reverse order of creation.
+// It returns whether every resource could be removed.
+func (d *Deployment) rollback(events chan<- Event) bool {
+	events <- Event{
+		ID:   RollbackDeployment,
+		Data: DeploymentPayload{Deployment: d.ID()},
+	}
+
+	if err := d.Teardown(context.Background(), events); err != nil {
+		events <- Event{
+			ID:   ErrorEvent,
+			Data: NewErrorPayload(fmt.Errorf("could not rollback deployment %s: %w", d.ID(), err)),
+		}
+
+		return false
+	}
+
+	return true
+}
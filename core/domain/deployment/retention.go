@@ -0,0 +1,74 @@
+package deployment
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/resource"
+)
+
+// Prune cleans up the deployments falling outside the retention policy.
+// The current deployment, deployments still in progress and deployments served by a proxy are never removed.
+func Prune(ctx context.Context, events chan<- Event, policy config.Retention, current *Deployment) error {
+	if !policy.IsEnabled() {
+		return nil
+	}
+
+	deployments, err := resource.List[Deployment](Store)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range expired(deployments, policy, current, time.Now()) {
+		inUse, err := d.InUse()
+		if err != nil {
+			return err
+		} else if inUse {
+			continue
+		}
+
+		d.Docker = current.Docker
+
+		if err = d.Cleanup(ctx, events); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expired returns the deployments that must be removed according to the retention policy.
+func expired(deployments []*Deployment, policy config.Retention, current *Deployment, now time.Time) []*Deployment {
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Time().After(deployments[j].Time())
+	})
+
+	var removable []*Deployment
+
+	// only succeeded deployments count towards Keep as the others can't be rolled back to
+	succeeded := 0
+
+	for _, d := range deployments {
+		if d.Status == StatusSucceeded {
+			succeeded++
+		}
+
+		if d.ID() == current.ID() || d.Status == StatusRunning {
+			continue
+		}
+
+		if policy.Keep > 0 && d.Status == StatusSucceeded && succeeded <= policy.Keep {
+			continue
+		}
+
+		if policy.MaxAge > 0 && now.Sub(d.Time()) < policy.MaxAge {
+			continue
+		}
+
+		removable = append(removable, d)
+	}
+
+	return removable
+}
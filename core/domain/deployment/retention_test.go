@@ -0,0 +1,142 @@
+package deployment
+
+import (
+	"context"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"gotest.tools/v3/assert"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func deploymentsAt(now time.Time, ages ...time.Duration) []*Deployment {
+	var deployments []*Deployment
+
+	for _, age := range ages {
+		deployments = append(deployments, &Deployment{
+			id:     strconv.FormatInt(now.Add(-age).UnixNano(), 10),
+			Status: StatusSucceeded,
+		})
+	}
+
+	return deployments
+}
+
+func ids(deployments []*Deployment) []string {
+	var got []string
+
+	for _, d := range deployments {
+		got = append(got, d.ID())
+	}
+
+	return got
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	deployments := deploymentsAt(now, time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour)
+
+	got := expired(deployments, config.Retention{Keep: 2}, deployments[0], now)
+	assert.DeepEqual(t, ids(got), ids(deployments[2:]))
+}
+
+func TestExpired2(t *testing.T) {
+	now := time.Now()
+	deployments := deploymentsAt(now, time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour)
+
+	got := expired(deployments, config.Retention{MaxAge: 150 * time.Minute}, deployments[0], now)
+	assert.DeepEqual(t, ids(got), ids(deployments[2:]))
+}
+
+func TestExpired3(t *testing.T) {
+	now := time.Now()
+	deployments := deploymentsAt(now, time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour)
+
+	// a deployment is kept if it satisfies any of the rules
+	got := expired(deployments, config.Retention{Keep: 1, MaxAge: 150 * time.Minute}, deployments[0], now)
+	assert.DeepEqual(t, ids(got), ids(deployments[2:]))
+}
+
+func TestExpired4(t *testing.T) {
+	now := time.Now()
+	deployments := deploymentsAt(now, time.Hour, 2*time.Hour, 3*time.Hour)
+	deployments[1].Status = StatusRunning
+
+	// the current deployment and running deployments are never removed
+	got := expired(deployments, config.Retention{Keep: 1}, deployments[2], now)
+	assert.Equal(t, len(got), 0)
+}
+
+func TestExpired5(t *testing.T) {
+	now := time.Now()
+	deployments := deploymentsAt(now, time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour)
+	deployments[1].Status = StatusFailed
+	deployments[2].Status = StatusRolledBack
+
+	// only succeeded deployments count towards Keep
+	got := expired(deployments, config.Retention{Keep: 2}, deployments[0], now)
+	assert.DeepEqual(t, ids(got), ids(deployments[1:3]))
+}
+
+func TestPrune(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "vite.yaml"), []byte("services: {}\n"), 0600))
+
+	l, err := locator.NewLocal(dir, "")
+	assert.NilError(t, err)
+
+	docker, err := runtime.NewClient()
+	assert.NilError(t, err)
+
+	subnetter, err := runtime.NewSubnetManager()
+	assert.NilError(t, err)
+
+	save := func(d *Deployment) {
+		err := resource.Save[*Deployment](Store, d, func(d *Deployment) string {
+			return d.ID()
+		})
+		assert.NilError(t, err)
+	}
+
+	// a failed deployment releases its subnet when it is rolled back
+	subnet, err := subnetter.Next()
+	assert.NilError(t, err)
+
+	failed := &Deployment{id: "1", Docker: docker, Locator: l, Status: StatusFailed}
+	failed.Add("subnet", "api", subnet.String())
+
+	events := make(chan Event, 10)
+	assert.Assert(t, failed.rollback(events))
+
+	failed.Status = StatusRolledBack
+	save(failed)
+
+	// the next deployment gets the same subnet
+	reallocated, err := subnetter.Next()
+	assert.NilError(t, err)
+	assert.Equal(t, reallocated.String(), subnet.String())
+
+	live := &Deployment{id: "2", Docker: docker, Locator: l, Status: StatusSucceeded}
+	live.Add("subnet", "api", reallocated.String())
+	save(live)
+
+	// pruning the rolled back deployment must not release the subnet of the live one
+	err = Prune(context.Background(), nil, config.Retention{Keep: 1}, live)
+	assert.NilError(t, err)
+
+	free, err := subnetter.IsFree(subnet.String())
+	assert.NilError(t, err)
+	assert.Assert(t, !free)
+
+	deployments, err := resource.List[Deployment](Store)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deployments), 1)
+}
@@ -0,0 +1,129 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+)
+
+const CreateVolume = "CreateVolume"
+
+// mounts creates the volumes of the service that do not exist yet and returns its mounts.
+// Volumes are not tracked by the deployment as they outlive it, they are only removed by PruneVolumes.
+func (d *Deployment) mounts(ctx context.Context, events chan<- Event, service *config.Service) ([]mount.Mount, error) {
+	var mounts []mount.Mount
+
+	for _, volume := range service.Volumes {
+		if volume.IsBind() {
+			mounts = append(mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   volume.Source,
+				Target:   volume.Target,
+				ReadOnly: volume.ReadOnly,
+			})
+			continue
+		}
+
+		created, err := d.Docker.VolumeCreate(ctx, volume.Source, map[string]string{
+			"cloud.vite.service": service.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		events <- Event{
+			ID:      CreateVolume,
+			Service: service,
+			Data:    VolumePayload{Volume: created.Name},
+		}
+
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   created.Name,
+			Target:   volume.Target,
+			ReadOnly: volume.ReadOnly,
+		})
+	}
+
+	return mounts, nil
+}
+
+// ReferencedVolumes returns the Docker names of the volumes managed by vite that are referenced by the config.
+func ReferencedVolumes(conf *config.Config) map[string]bool {
+	referenced := make(map[string]bool)
+
+	for _, service := range conf.Services {
+		for _, volume := range service.Volumes {
+			if !volume.IsBind() {
+				referenced[runtime.VolumeName(volume.Source)] = true
+			}
+		}
+	}
+
+	return referenced
+}
+
+// currentVolumes returns the volumes referenced by the config of the latest deployment.
+func currentVolumes() (map[string]bool, error) {
+	latest, err := Latest()
+	if errors.Is(err, ErrNoDeployment) {
+		return map[string]bool{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	conf, err := config.Get(latest.Locator)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the config of the latest deployment: %w", err)
+	}
+
+	return ReferencedVolumes(conf), nil
+}
+
+// PruneVolumes removes the volumes managed by vite that are neither referenced by the config
+// of the latest deployment nor used by a container, and returns their names.
+func PruneVolumes(ctx context.Context, docker *runtime.Client) ([]string, error) {
+	referenced, err := currentVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := docker.VolumeList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+
+	for _, volume := range unreferenced(volumes, referenced) {
+		err = docker.VolumeRemove(ctx, volume.Name)
+		// The volume is still used by the container of a previous deployment.
+		if errdefs.IsConflict(err) {
+			continue
+		} else if err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, volume.Name)
+	}
+
+	return removed, nil
+}
+
+// unreferenced returns the volumes whose name is not in referenced.
+func unreferenced(volumes []*types.Volume, referenced map[string]bool) []*types.Volume {
+	var filtered []*types.Volume
+
+	for _, volume := range volumes {
+		if !referenced[volume.Name] {
+			filtered = append(filtered, volume)
+		}
+	}
+
+	return filtered
+}
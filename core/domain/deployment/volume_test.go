@@ -0,0 +1,34 @@
+package deployment
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestReferencedVolumes(t *testing.T) {
+	conf := &config.Config{
+		Services: map[string]*config.Service{
+			"db": {Volumes: []config.Volume{
+				{Source: "pgdata", Target: "/var/lib/postgresql/data"},
+				{Source: "/etc/ssl", Target: "/etc/ssl"},
+			}},
+			"cache": {Volumes: []config.Volume{{Source: "redis", Target: "/data"}}},
+		},
+	}
+
+	assert.DeepEqual(t, ReferencedVolumes(conf), map[string]bool{
+		"vite_pgdata": true,
+		"vite_redis":  true,
+	})
+}
+
+func TestUnreferenced(t *testing.T) {
+	volumes := []*types.Volume{{Name: "vite_pgdata"}, {Name: "vite_old"}}
+
+	got := unreferenced(volumes, map[string]bool{"vite_pgdata": true})
+
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].Name, "vite_old")
+}
@@ -22,19 +22,62 @@ var (
 )
 
 // Clone clones the given repository and branch.
-func (g Git) Clone(remote, branch string) error {
+// Only commits and trees are downloaded, files are fetched when read and never checked out.
+// The environment variables, if any, are added to the ones of the git process.
+func (g Git) Clone(remote, branch string, env ...string) error {
 	if branch == "" {
 		return ErrEmptyBranch
 	}
 
-	cmd := exec.Command("git", "clone", "--branch", branch, remote, g.String())
+	cmd := exec.Command("git", "clone", "--filter=blob:none", "--no-checkout", "--sparse", "--branch", branch, remote, g.String())
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
 	_, err := globalRun(cmd)
 	return err
 }
 
+// Fetch fetches the branches and tags of the remote.
+// The environment variables, if any, are added to the ones of the git process.
+func (g Git) Fetch(env ...string) error {
+	_, err := g.runWithEnv(env, "fetch", "--tags", "--prune", "--force", "origin")
+	return err
+}
+
+// FetchCommit fetches a single commit from the remote.
+// The environment variables, if any, are added to the ones of the git process.
+func (g Git) FetchCommit(commit string, env ...string) error {
+	if commit == "" || strings.HasPrefix(commit, "-") {
+		return fmt.Errorf("%w: %q", ErrInvalidCommit, commit)
+	}
+
+	_, err := g.runWithEnv(env, "fetch", "origin", commit)
+	return err
+}
+
+// SparseCheckout restricts the files that may be checked out to the given directory.
+// The files of the directory may be fetched from the remote, using the given environment.
+func (g Git) SparseCheckout(dir string, env ...string) error {
+	dir = strings.Trim(dir, "/")
+	if dir == "" || strings.HasPrefix(dir, "-") {
+		return nil
+	}
+
+	_, err := g.runWithEnv(env, "sparse-checkout", "set", dir)
+	return err
+}
+
+// HasCommit returns true if the given commit exists in the repository.
+func (g Git) HasCommit(commit string) bool {
+	_, err := g.run("cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
 // Read reads a given file at a given revision and returns its contents.
-func (g Git) Read(commit, path string) ([]byte, error) {
-	return g.run("show", commit+":"+path)
+// As clones are partial, the file may be fetched from the remote, using the given environment.
+func (g Git) Read(commit, path string, env ...string) ([]byte, error) {
+	return g.runWithEnv(env, "show", commit+":"+path)
 }
 
 // String returns the path to the repository.
@@ -65,6 +108,11 @@ func globalRun(cmd *exec.Cmd) ([]byte, error) {
 
 // run a git command with the given arguments.
 func (g Git) run(args ...string) ([]byte, error) {
+	return g.runWithEnv(nil, args...)
+}
+
+// runWithEnv runs a git command with the given environment variables added to the ones of the git process.
+func (g Git) runWithEnv(env []string, args ...string) ([]byte, error) {
 	if _, err := os.Stat(g.String()); errors.Is(err, os.ErrNotExist) {
 		return nil, ErrRepositoryNotFound
 	} else if err != nil {
@@ -73,6 +121,9 @@ func (g Git) run(args ...string) ([]byte, error) {
 
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.String()
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	return globalRun(cmd)
 }
@@ -83,6 +134,36 @@ func (g Git) RepoExists() bool {
 	return !errors.Is(err, os.ErrNotExist)
 }
 
+// Message returns the subject of the given commit.
+func (g Git) Message(commit string) (string, error) {
+	out, err := g.run("log", "-1", "--pretty=%s", commit)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ResolveCommit returns the hash of the commit the given ref points to.
+// The ref may be a commit, a tag or a branch, in which case its head is used.
+func (g Git) ResolveCommit(ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCommit, ref)
+	}
+
+	// Remote branches come first as local ones are never updated by Fetch.
+	for _, candidate := range []string{"origin/" + ref, ref} {
+		out, err := g.run("rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if errors.Is(err, ErrRepositoryNotFound) {
+			return "", err
+		} else if err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s does not match any commit, branch or tag", ErrInvalidCommit, ref)
+}
+
 type CommitList []Commit
 
 type Commit struct {
@@ -99,3 +99,33 @@ func TestGit_Run(t *testing.T) {
 	_, err := Git("\x00").run("init")
 	assert.DeepEqual(t, err, &fs.PathError{Op: "stat", Err: syscall.Errno(0x16), Path: "\x00"})
 }
+
+func TestGit_ResolveCommit(t *testing.T) {
+	dir := t.TempDir()
+
+	repo := newLocalRepo(t, dir)
+	first := repo.WriteFile("a", []byte{}, 0600).Commit()
+	runGit(t, dir, "tag", "v1.0.0")
+	second := repo.WriteFile("b", []byte{}, 0600).Commit()
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{first, first},
+		{first[:7], first},
+		{"v1.0.0", first},
+		{"main", second},
+	}
+
+	for _, test := range tests {
+		got, err := repo.Git().ResolveCommit(test.ref)
+		assert.NilError(t, err)
+		assert.Equal(t, got, test.want)
+	}
+
+	for _, ref := range []string{"", "unknown", "--all"} {
+		_, err := repo.Git().ResolveCommit(ref)
+		assert.ErrorIs(t, err, ErrInvalidCommit)
+	}
+}
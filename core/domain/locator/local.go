@@ -0,0 +1,143 @@
+package locator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ViteFile is the name of the config file.
+const ViteFile = "vite.yaml"
+
+// ConfigFiles returns the files the config is read from, in the order they are merged:
+// the ViteFile and the overlay of the environment, if any.
+func (l *Locator) ConfigFiles() []string {
+	if l.Environment == "" {
+		return []string{ViteFile}
+	}
+
+	return []string{ViteFile, "vite." + l.Environment + ".yaml"}
+}
+
+// ErrLocalChanged is returned when reading a local config that changed since its hash was pinned.
+var ErrLocalChanged = errors.New("the local config changed since it was pinned, run `vite use --latest` to use the new one")
+
+// NewLocal returns a locator reading the config from the given directory, or file, pinned to its current contents.
+// The environment, if any, selects the overlay merged over the ViteFile.
+func NewLocal(path, environment string) (*Locator, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Locator{Local: abs, Environment: environment}
+
+	l.Commit, err = l.ResolveCommit(LatestRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// IsLocal returns true if the config is read from the disk instead of a repository.
+func (l *Locator) IsLocal() bool {
+	return l.Local != ""
+}
+
+// localFile returns the path of the given file on the disk.
+// If the locator points to a single file, it is used as the ViteFile and other files are read next to it.
+func (l *Locator) localFile(file string) (string, error) {
+	info, err := os.Stat(l.Local)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return filepath.Join(l.Local, file), nil
+	}
+
+	if file == ViteFile {
+		return l.Local, nil
+	}
+
+	return filepath.Join(filepath.Dir(l.Local), file), nil
+}
+
+// readLocal reads a file from the disk, the config files must match the pinned hash.
+func (l *Locator) readLocal(file string) ([]byte, error) {
+	commit, err := l.localCommit()
+	if err != nil {
+		return nil, err
+	}
+
+	if commit != l.Commit {
+		return nil, ErrLocalChanged
+	}
+
+	path, err := l.localFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+// localCommit returns the hash of the current contents of the local config files.
+func (l *Locator) localCommit() (string, error) {
+	var files [][]byte
+
+	for _, file := range l.ConfigFiles() {
+		path, err := l.localFile(file)
+		if err != nil {
+			return "", err
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		files = append(files, contents)
+	}
+
+	return ContentHash(files...), nil
+}
+
+// resolveLocal returns the hash of the local config, only its latest contents may be used.
+func (l *Locator) resolveLocal(ref string) (string, error) {
+	commit, err := l.localCommit()
+	if err != nil {
+		return "", err
+	}
+
+	if ref != LatestRef && ref != commit {
+		return "", fmt.Errorf("%w: local configs can only use their latest contents (%s)", ErrInvalidCommit, commit)
+	}
+
+	return commit, nil
+}
+
+// localMessage describes the local config in place of a commit message.
+func (l *Locator) localMessage() string {
+	return "local config at " + l.Local
+}
+
+// ContentHash returns the hash of the files of a local config, it is used as its commit.
+// The hash of a single file is the sha256 of its contents.
+func ContentHash(files ...[]byte) string {
+	h := sha256.New()
+
+	for i, contents := range files {
+		if i > 0 {
+			h.Write([]byte{0})
+		}
+
+		h.Write(contents)
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
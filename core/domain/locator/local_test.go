@@ -0,0 +1,89 @@
+package locator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNewLocal(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, ViteFile), []byte("first"), 0600)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("other"), 0600)
+	assert.NilError(t, err)
+
+	// both a directory and the file itself may be used
+	for _, path := range []string{dir, filepath.Join(dir, ViteFile)} {
+		l, err := NewLocal(path, "")
+		assert.NilError(t, err)
+		assert.Equal(t, l.Commit, ContentHash([]byte("first")))
+
+		contents, err := l.Read(ViteFile)
+		assert.NilError(t, err)
+		assert.Equal(t, string(contents), "first")
+
+		contents, err = l.Read("other.yaml")
+		assert.NilError(t, err)
+		assert.Equal(t, string(contents), "other")
+	}
+}
+
+func TestNewLocal2(t *testing.T) {
+	_, err := NewLocal(filepath.Join(t.TempDir(), "does-not-exist"), "")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLocator_ReadLocal(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, ViteFile), []byte("first"), 0600)
+	assert.NilError(t, err)
+
+	l, err := NewLocal(dir, "")
+	assert.NilError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, ViteFile), []byte("second"), 0600)
+	assert.NilError(t, err)
+
+	_, err = l.Read(ViteFile)
+	assert.ErrorIs(t, err, ErrLocalChanged)
+
+	_, err = l.ResolveCommit(l.Commit)
+	assert.ErrorIs(t, err, ErrInvalidCommit)
+
+	commit, err := l.ResolveCommit(LatestRef)
+	assert.NilError(t, err)
+	assert.Equal(t, commit, ContentHash([]byte("second")))
+
+	commits, err := l.Commits()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, commits, CommitList{{Hash: commit, Message: "local config at " + dir}})
+}
+
+func TestNewLocal3(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, ViteFile), []byte("base"), 0600)
+	assert.NilError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "vite.staging.yaml"), []byte("first"), 0600)
+	assert.NilError(t, err)
+
+	l, err := NewLocal(dir, "staging")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, l.ConfigFiles(), []string{ViteFile, "vite.staging.yaml"})
+	assert.Equal(t, l.Commit, ContentHash([]byte("base"), []byte("first")))
+
+	// the overlay is part of the hash
+	err = os.WriteFile(filepath.Join(dir, "vite.staging.yaml"), []byte("second"), 0600)
+	assert.NilError(t, err)
+
+	_, err = l.Read(ViteFile)
+	assert.ErrorIs(t, err, ErrLocalChanged)
+
+	_, err = NewLocal(dir, "production")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -8,9 +8,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/vite-cloud/go-zoup"
 	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/log"
 )
 
 const (
@@ -20,20 +23,42 @@ const (
 	ConfigFile = "config.json"
 )
 
+// Protocols used to clone the repository.
+const (
+	ProtocolSSH   = "ssh"
+	ProtocolHTTPS = "https"
+	// ProtocolAuto tries ssh first and falls back to https.
+	ProtocolAuto = "auto"
+)
+
 // ErrInvalidCommit is returned when the commit is invalid.
 var ErrInvalidCommit = errors.New("invalid commit")
 
+// unsafeChars matches the characters of a remote URL that may not be used in a file name.
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
 // Locator contains the configuration for the locator.
 // Do not 'omitempty' on fields in this struct, as we test if the Checksum
 // contains all the fields by marshaling it to JSON. Therefore, if you omit
 // empty fields, test may be false-negative.
 type Locator struct {
-	Provider   Provider `json:"provider"`
-	Protocol   string   `json:"protocol"`
-	Repository string   `json:"repository"`
-	Branch     string   `json:"branch"`
-	Commit     string   `json:"commit"`
-	Path       string   `json:"path"`
+	Provider Provider `json:"provider"`
+	Protocol string   `json:"protocol"`
+	// Host overrides the host of the provider, e.g. for a self-hosted GitLab.
+	Host string `json:"host"`
+	// Remote is the full URL of the repository, it takes precedence over the provider, protocol and host.
+	Remote string `json:"remote"`
+	// SSHCommand is set as GIT_SSH_COMMAND, e.g. to select a deploy key.
+	SSHCommand string `json:"ssh_command"`
+	// Local is the directory, or file, the config is read from instead of a repository.
+	// The commit is then the hash of the config.
+	Local      string `json:"local"`
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	Commit     string `json:"commit"`
+	Path       string `json:"path"`
+	// Environment selects the overlay merged over the ViteFile, e.g. vite.staging.yaml for staging.
+	Environment string `json:"environment"`
 }
 
 // Read a file from the repository.
@@ -42,22 +67,30 @@ func (l *Locator) Read(file string) ([]byte, error) {
 		return nil, ErrInvalidCommit
 	}
 
+	if l.IsLocal() {
+		return l.readLocal(file)
+	}
+
 	git, err := l.git()
 	if err != nil {
 		return nil, err
 	}
 
 	if !git.RepoExists() {
-		err = git.Clone(l.Provider.URL(l.Protocol, l.Repository), l.Branch)
-		if errors.Is(err, ErrEmptyBranch) {
-			return nil, fmt.Errorf("could not clone repository %s: no branch specified (run `vite setup` again)", l.Provider.URL(l.Protocol, l.Repository))
+		if err = l.Clone(); err != nil {
+			return nil, err
 		}
-		if err != nil {
+	}
+
+	// The commit may have been pushed after the repository was cloned.
+	// Only the commit is fetched, unless the remote does not allow it.
+	if !git.HasCommit(l.Commit) && git.FetchCommit(l.Commit, l.env()...) != nil {
+		if err = git.Fetch(l.env()...); err != nil {
 			return nil, err
 		}
 	}
 
-	contents, err := git.Read(l.Commit, filepath.Join(l.Path, file))
+	contents, err := git.Read(l.Commit, filepath.Join(l.Path, file), l.env()...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,19 +105,64 @@ func (l *Locator) git() (Git, error) {
 		return "", err
 	}
 
-	path := dir + "/" + l.Branch + "-" + strings.Replace(l.Repository, "/", "-", -1)
+	path := dir + "/" + l.Branch + "-" + l.name()
 	return Git(path), nil
 }
 
-// Save the locator to the config store.
-func (l *Locator) Save() error {
-	dir, err := Store.Dir()
-	if err != nil {
-		return err
+// name returns a name unique to the repository that may be used as a file name.
+func (l *Locator) name() string {
+	if l.Remote != "" {
+		return unsafeChars.ReplaceAllString(l.Remote, "-")
+	}
+
+	name := strings.Replace(l.Repository, "/", "-", -1)
+	if l.Host != "" {
+		return l.Host + "-" + name
+	}
+
+	return name
+}
+
+// remotes returns the URLs to clone the repository from, in the order they should be tried.
+func (l *Locator) remotes() []string {
+	if l.Remote != "" {
+		return []string{l.Remote}
 	}
 
+	host := l.Host
+	if host == "" {
+		host = l.Provider.Host()
+	}
+
+	if l.Protocol == ProtocolAuto {
+		return []string{
+			HostURL(host, ProtocolSSH, l.Repository),
+			HostURL(host, ProtocolHTTPS, l.Repository),
+		}
+	}
+
+	return []string{HostURL(host, l.Protocol, l.Repository)}
+}
+
+// URL returns the URL of the repository, ssh is used if the protocol is auto.
+func (l *Locator) URL() string {
+	return l.remotes()[0]
+}
+
+// env returns the environment variables of the git commands reaching the remote.
+func (l *Locator) env() []string {
+	if l.SSHCommand == "" {
+		return nil
+	}
+
+	return []string{"GIT_SSH_COMMAND=" + l.SSHCommand}
+}
+
+// Save the locator to the config store.
+func (l *Locator) Save() error {
 	contents, _ := json.Marshal(l)
-	return os.WriteFile(filepath.Join(dir, ConfigFile), contents, 0600)
+
+	return Store.WriteFile(ConfigFile, contents, 0600)
 }
 
 // LoadFromStore loads a Locator from a config.json in store or fails if it does not exist.
@@ -107,27 +185,133 @@ func LoadFromStore() (*Locator, error) {
 }
 
 // Checksum returns the unique id of the locator, it may be used as a file name.
+// Fields are separated by a NUL byte, which none of them may contain, so that distinct locators never collide.
 func (l *Locator) Checksum() string {
-	return base64.StdEncoding.EncodeToString([]byte(l.Branch + l.Repository + l.Provider.Name() + l.Commit + l.Path))
+	fields := []string{l.Branch, l.Repository, l.Provider.Name(), l.Host, l.Remote, l.Local, l.Commit, l.Path, l.Environment}
+
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(fields, "\x00")))
+}
+
+// Fetch clones the repository if needed or fetches its latest commits and tags.
+// Local configs have nothing to fetch.
+func (l *Locator) Fetch() error {
+	if l.IsLocal() {
+		return nil
+	}
+
+	git, err := l.git()
+	if err != nil {
+		return err
+	}
+
+	if !git.RepoExists() {
+		return l.Clone()
+	}
+
+	return git.Fetch(l.env()...)
 }
 
+// Commits fetches the repository and returns the commits of the locator's branch.
 func (l *Locator) Commits() (CommitList, error) {
+	if l.IsLocal() {
+		commit, err := l.localCommit()
+		if err != nil {
+			return nil, err
+		}
+
+		return CommitList{{Hash: commit, Message: l.localMessage()}}, nil
+	}
+
 	git, err := l.git()
 	if err != nil {
 		return nil, err
 	}
 
-	return git.Commits(l.Branch)
+	if l.Branch == "" {
+		return nil, ErrEmptyBranch
+	}
+
+	if err = l.Fetch(); err != nil {
+		return nil, err
+	}
+
+	return git.Commits("origin/" + l.Branch)
 }
+
+// LatestRef resolves to the head of the locator's branch.
+const LatestRef = "HEAD"
+
+// ResolveCommit fetches the repository and returns the hash of the commit the given ref points to.
+// The ref may be a commit, a tag, a branch or LatestRef.
+func (l *Locator) ResolveCommit(ref string) (string, error) {
+	if l.IsLocal() {
+		return l.resolveLocal(ref)
+	}
+
+	if ref == LatestRef {
+		ref = l.Branch
+	}
+
+	if err := l.Fetch(); err != nil {
+		return "", err
+	}
+
+	git, err := l.git()
+	if err != nil {
+		return "", err
+	}
+
+	return git.ResolveCommit(ref)
+}
+
+// CommitMessage returns the message of the locator's commit.
+func (l *Locator) CommitMessage() (string, error) {
+	if l.Commit == "" {
+		return "", ErrInvalidCommit
+	}
+
+	if l.IsLocal() {
+		return l.localMessage(), nil
+	}
+
+	git, err := l.git()
+	if err != nil {
+		return "", err
+	}
+
+	return git.Message(l.Commit)
+}
+
+// Clone clones the repository, falling back to https if the protocol is auto and ssh failed.
 func (l *Locator) Clone() error {
 	git, err := l.git()
 	if err != nil {
 		return err
 	}
 
-	err = git.Clone(l.Provider.URL(l.Protocol, l.Repository), l.Branch)
-	if errors.Is(err, ErrEmptyBranch) {
-		return fmt.Errorf("could not clone repository %s: no branch specified (run `vite setup` again)", l.Provider.URL(l.Protocol, l.Repository))
+	remotes := l.remotes()
+
+	for i, remote := range remotes {
+		env := l.env()
+		// ssh must not prompt for a passphrase or a host key when https may be used instead.
+		if i < len(remotes)-1 && env == nil {
+			env = []string{"GIT_SSH_COMMAND=ssh -o BatchMode=yes"}
+		}
+
+		err = git.Clone(remote, l.Branch, env...)
+		if errors.Is(err, ErrEmptyBranch) {
+			return fmt.Errorf("could not clone repository %s: no branch specified (run `vite setup` again)", remote)
+		}
+		if err == nil {
+			return git.SparseCheckout(l.Path, l.env()...)
+		}
+
+		if i < len(remotes)-1 {
+			log.Log(zoup.WarnLevel, "could not clone repository, trying next remote", zoup.Fields{
+				"remote": remote,
+				"error":  err,
+			})
+		}
 	}
 
 	return err
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/vite-cloud/vite/core/domain/datadir"
@@ -191,12 +192,17 @@ func TestLocator_Checksum(t *testing.T) {
 	t.Parallel()
 
 	locator := Locator{
-		Provider:   Provider("foo"),
-		Protocol:   "ssh",
-		Repository: "foo/bar",
-		Branch:     "main",
-		Commit:     "fffffff",
-		Path:       "/sub/path",
+		Provider:    Provider("foo"),
+		Protocol:    "ssh",
+		Host:        "git.example.com",
+		Remote:      "ssh://git.example.com/foo/bar.git",
+		SSHCommand:  "ssh -i deploy_key",
+		Local:       "/srv/config",
+		Repository:  "foo/bar",
+		Branch:      "main",
+		Commit:      "fffffff",
+		Path:        "/sub/path",
+		Environment: "staging",
 	}
 
 	data, err := json.Marshal(locator)
@@ -210,7 +216,8 @@ func TestLocator_Checksum(t *testing.T) {
 	assert.NilError(t, err)
 
 	for k, v := range values {
-		if k == "protocol" {
+		// both change how the repository is reached, not its contents
+		if k == "protocol" || k == "ssh_command" {
 			continue
 		}
 
@@ -218,6 +225,16 @@ func TestLocator_Checksum(t *testing.T) {
 	}
 }
 
+func TestLocator_Checksum2(t *testing.T) {
+	t.Parallel()
+
+	// fields are separated so that moving characters from one to the next changes the checksum
+	a := Locator{Repository: "foo/bar", Branch: "main", Path: "sub"}
+	b := Locator{Repository: "foo/bar", Branch: "mainsub"}
+
+	assert.Assert(t, a.Checksum() != b.Checksum())
+}
+
 func TestLocator_Commits(t *testing.T) {
 	datadir.UseTestHome(t)
 
@@ -229,10 +246,13 @@ func TestLocator_Commits(t *testing.T) {
 	dir, err := Store.Dir()
 	assert.NilError(t, err)
 
-	repo := newLocalRepo(t, dir+"/main-foo-bar")
+	remote := newLocalRepo(t, t.TempDir())
 
-	first := repo.WriteFile("hello-world", []byte{}, 0600).Commit()
-	second := repo.WriteFile("2hello-world", []byte{}, 0600).Commit()
+	first := remote.WriteFile("hello-world", []byte{}, 0600).Commit()
+	runGit(t, dir, "clone", "--branch", "main", remote.path, dir+"/main-foo-bar")
+
+	// commits pushed after the repository was cloned are fetched
+	second := remote.WriteFile("2hello-world", []byte{}, 0600).Commit()
 
 	commits, err := locator.Commits()
 	assert.NilError(t, err)
@@ -244,6 +264,42 @@ func TestLocator_Commits(t *testing.T) {
 	assert.Equal(t, commits[1].Message, "commit")
 }
 
+func TestLocator_ResolveCommit(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	locator := Locator{
+		Branch:     "main",
+		Repository: "foo/bar",
+	}
+
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+
+	remote := newLocalRepo(t, t.TempDir())
+
+	first := remote.WriteFile("a", []byte{}, 0600).Commit()
+	runGit(t, dir, "clone", "--branch", "main", remote.path, dir+"/main-foo-bar")
+
+	second := remote.WriteFile("b", []byte{}, 0600).Commit()
+	runGit(t, remote.path, "tag", "v2")
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{LatestRef, second},
+		{"main", second},
+		{"v2", second},
+		{first, first},
+	}
+
+	for _, test := range tests {
+		got, err := locator.ResolveCommit(test.ref)
+		assert.NilError(t, err)
+		assert.Equal(t, got, test.want, test.ref)
+	}
+}
+
 func TestLocator_Commits2(t *testing.T) {
 	datadir.SetHomeDir("/nop")
 
@@ -252,3 +308,108 @@ func TestLocator_Commits2(t *testing.T) {
 	_, err := locator.Commits()
 	assert.ErrorIs(t, err, os.ErrPermission)
 }
+
+func TestLocator_remotes(t *testing.T) {
+	tests := []struct {
+		locator Locator
+		remotes []string
+	}{
+		{Locator{Provider: "github", Protocol: "ssh", Repository: "foo/bar"}, []string{"git@github.com:foo/bar.git"}},
+		{Locator{Provider: "gitlab", Protocol: "https", Host: "git.example.com", Repository: "foo/bar"}, []string{"https://git.example.com/foo/bar.git"}},
+		{Locator{Provider: "github", Protocol: "auto", Repository: "foo/bar"}, []string{"git@github.com:foo/bar.git", "https://github.com/foo/bar.git"}},
+		{Locator{Provider: "github", Protocol: "ssh", Remote: "file:///srv/config.git", Repository: "foo/bar"}, []string{"file:///srv/config.git"}},
+	}
+
+	for _, test := range tests {
+		assert.DeepEqual(t, test.locator.remotes(), test.remotes)
+	}
+}
+
+func TestLocator_env(t *testing.T) {
+	assert.Assert(t, (&Locator{}).env() == nil)
+	assert.DeepEqual(t, (&Locator{SSHCommand: "ssh -i key"}).env(), []string{"GIT_SSH_COMMAND=ssh -i key"})
+}
+
+func TestLocator_Clone(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	remote := newLocalRepo(t, t.TempDir())
+	commit := remote.WriteFile("vite.yaml", []byte("hello"), 0600).Commit()
+
+	locator := Locator{
+		Remote: "file://" + remote.path,
+		Branch: "main",
+		Commit: commit,
+	}
+
+	contents, err := locator.Read("vite.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "hello")
+
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+
+	git, err := locator.git()
+	assert.NilError(t, err)
+	assert.Equal(t, git.String(), dir+"/main-file"+unsafeChars.ReplaceAllString(remote.path, "-"))
+}
+
+func TestLocator_Clone2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	remote := newLocalRepo(t, t.TempDir())
+	runGit(t, remote.path, "config", "uploadpack.allowFilter", "true")
+	remote.WriteFile("config/vite.yaml", []byte("first"), 0600).Commit()
+
+	locator := Locator{
+		Remote: "file://" + remote.path,
+		Branch: "main",
+		Path:   "config",
+	}
+
+	assert.NilError(t, locator.Clone())
+
+	git, err := locator.git()
+	assert.NilError(t, err)
+
+	// files are not downloaded until they are read
+	out, err := git.run("config", "remote.origin.partialclonefilter")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), "blob:none")
+
+	out, err = git.run("sparse-checkout", "list")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), "config")
+
+	// commits pushed after the repository was cloned are fetched when read
+	locator.Commit = remote.WriteFile("config/vite.yaml", []byte("second"), 0600).Commit()
+
+	contents, err := locator.Read("vite.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "second")
+}
+
+func TestLocator_Clone3(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	remote := newLocalRepo(t, t.TempDir())
+	runGit(t, remote.path, "config", "uploadpack.allowFilter", "true")
+	commit := remote.WriteFile("vite.yaml", []byte("hello"), 0600).Commit()
+
+	// the ssh command runs the git command it is given locally, as a deploy key would be used to reach the remote
+	ssh := filepath.Join(t.TempDir(), "ssh")
+	err := os.WriteFile(ssh, []byte("#!/bin/sh\nfor last; do :; done\nexec sh -c \"$last\"\n"), 0700)
+	assert.NilError(t, err)
+
+	locator := Locator{
+		Remote:     "ssh://git@example.invalid" + remote.path,
+		Branch:     "main",
+		Commit:     commit,
+		SSHCommand: ssh,
+	}
+
+	// the file is only fetched when it is read, the ssh command must still be used
+	contents, err := locator.Read("vite.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "hello")
+}
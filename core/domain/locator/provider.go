@@ -1,6 +1,9 @@
 package locator
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Provider is a locator provider such as GitHub, GitLab...
 type Provider string
@@ -10,14 +13,29 @@ func (p *Provider) Name() string {
 	return string(*p)
 }
 
+// Host returns the host of the provider's public instance (e.g. github.com)
+func (p *Provider) Host() string {
+	return p.Name() + ".com"
+}
+
 // URL returns the repository url given a protocol (ssh, https) and a repository name
 func (p *Provider) URL(protocol, repository string) string {
+	return HostURL(p.Host(), protocol, repository)
+}
+
+// HostURL returns the url of a repository on the given host, such as a self-hosted GitLab.
+// The host may include a port, ssh URLs then use the ssh:// form as git@host:path has no port.
+func HostURL(host, protocol, repository string) string {
 	switch protocol {
-	case "ssh":
-		return fmt.Sprintf("git@%s.com:%s.git", p.Name(), repository)
-	case "https":
-		return fmt.Sprintf("https://%s.com/%s.git", p.Name(), repository)
+	case ProtocolSSH:
+		if strings.Contains(host, ":") {
+			return fmt.Sprintf("ssh://git@%s/%s.git", host, repository)
+		}
+
+		return fmt.Sprintf("git@%s:%s.git", host, repository)
+	case ProtocolHTTPS:
+		return fmt.Sprintf("https://%s/%s.git", host, repository)
 	default:
-		return fmt.Sprintf("git://%s.com/%s.git", p.Name(), repository)
+		return fmt.Sprintf("git://%s/%s.git", host, repository)
 	}
 }
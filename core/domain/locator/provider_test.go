@@ -18,3 +18,9 @@ func TestProvider_URL(t *testing.T) {
 	assert.Equal(t, p.URL("https", "foo/bar"), "https://github.com/foo/bar.git")
 	assert.Equal(t, p.URL("", "foo/bar"), "git://github.com/foo/bar.git")
 }
+
+func TestHostURL(t *testing.T) {
+	assert.Equal(t, HostURL("git.example.com", "ssh", "foo/bar"), "git@git.example.com:foo/bar.git")
+	assert.Equal(t, HostURL("git.example.com:8443", "https", "foo/bar"), "https://git.example.com:8443/foo/bar.git")
+	assert.Equal(t, HostURL("git.example.com:2222", "ssh", "foo/bar"), "ssh://git@git.example.com:2222/foo/bar.git")
+}
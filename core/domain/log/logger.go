@@ -9,6 +9,9 @@ import (
 // logger contains an instance of the global logger.
 var logger zoup.Writer
 
+// redactor masks sensitive values in the fields of log entries, if set.
+var redactor func(string) string
+
 const (
 	// Store is the unique name of the logger store
 	Store = datadir.Store("logs")
@@ -26,6 +29,36 @@ func GetLogger() zoup.Writer {
 	return logger
 }
 
+// SetRedactor sets the function used to mask sensitive values, such as passwords,
+// in the string, error and []string fields of log entries.
+func SetRedactor(r func(string) string) {
+	redactor = r
+}
+
+// redact returns a copy of the fields in which sensitive values are masked.
+func redact(fields zoup.Fields) zoup.Fields {
+	redacted := make(zoup.Fields, len(fields))
+
+	for k, v := range fields {
+		switch value := v.(type) {
+		case string:
+			redacted[k] = redactor(value)
+		case error:
+			redacted[k] = redactor(value.Error())
+		case []string:
+			values := make([]string, len(value))
+			for i, s := range value {
+				values[i] = redactor(s)
+			}
+			redacted[k] = values
+		default:
+			redacted[k] = v
+		}
+	}
+
+	return redacted
+}
+
 // defaultLogger creates a default logger.
 func defaultLogger() (zoup.Writer, error) {
 	dir, err := Store.Dir()
@@ -52,6 +85,10 @@ func Log(level zoup.Level, message string, fields zoup.Fields) {
 		SetLogger(w)
 	}
 
+	if redactor != nil {
+		fields = redact(fields)
+	}
+
 	err := logger.Write(level, message, fields)
 	if err != nil {
 		panic(err)
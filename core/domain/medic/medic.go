@@ -3,9 +3,9 @@ package medic
 import (
 	"context"
 	"fmt"
-	"github.com/docker/docker/api/types"
 	"github.com/vite-cloud/vite/core/domain/config"
 	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/metrics"
 	"github.com/vite-cloud/vite/core/domain/runtime"
 	"io/ioutil"
 	"net"
@@ -48,6 +48,8 @@ func Diagnose(config *config.Config) *Diagnostic {
 
 	diagnostic.ensureDnsRecordsPointToHost()
 
+	diagnostic.diagnoseResources()
+
 	_, err = url.Parse(config.ControlPlane.Host)
 	ok := diagnostic.ErrorIf(
 		err != nil,
@@ -64,7 +66,8 @@ func Diagnose(config *config.Config) *Diagnostic {
 func (d *Diagnostic) diagnoseService(service *config.Service) {
 	d.ErrorIf(service.Image == "", fmt.Sprintf("Service %s has no image", service.Name), nil)
 
-	re := regexp.MustCompile(`^[a-zA-Z0-9-]+:([a-zA-Z0-9.]+)$`)
+	// the image may include the host of its registry, e.g. registry.example.com:5000/team/app:1.0
+	re := regexp.MustCompile(`^(?:[a-zA-Z0-9.-]+(?::[0-9]+)?/)?[a-zA-Z0-9._/-]+:([a-zA-Z0-9._-]+)$`)
 	ok := d.ErrorIf(
 		!re.MatchString(service.Image),
 		fmt.Sprintf("Service %s has an invalid image", service.Name),
@@ -76,8 +79,8 @@ func (d *Diagnostic) diagnoseService(service *config.Service) {
 		nil,
 	)
 
-	if service.Registry != nil {
-		d.diagnoseRegistry(*service.Registry)
+	if service.Registry != nil || service.CredentialHelper != "" {
+		d.diagnoseRegistry(service)
 	}
 
 	if service.IsTopLevel && len(service.Hosts) == 0 {
@@ -112,6 +115,47 @@ func (d *Diagnostic) diagnoseService(service *config.Service) {
 	}
 }
 
+// diagnoseResources warns if the memory limits of the services exceed the memory of the host.
+func (d *Diagnostic) diagnoseResources() {
+	limits := memoryLimits(d.Config.Services)
+	if limits == 0 {
+		return
+	}
+
+	gathered, err := metrics.Gather()
+	if err != nil {
+		d.Warnings = append(d.Warnings, Warning{
+			Title:  "Could not check the memory limits of your services",
+			Advice: fmt.Sprintf("Failed to gather metrics about the host: %s", err),
+		})
+		return
+	}
+
+	total := gathered.SystemMetrics.MemoryTotal
+
+	d.WarningIf(
+		limits > total,
+		fmt.Sprintf("The memory limits of your services (%s) exceed the memory of the host (%s)", limits, total),
+		"Services may be killed when the host runs out of memory, consider lowering resources.memory.",
+	)
+}
+
+// memoryLimits returns the sum of the memory limits of every replica of the given services.
+func memoryLimits(services map[string]*config.Service) metrics.ByteSize {
+	var limits metrics.ByteSize
+
+	for _, service := range services {
+		replicas := service.Replicas
+		if replicas < 1 {
+			replicas = 1
+		}
+
+		limits += metrics.ByteSize(service.Resources.Memory) * metrics.ByteSize(replicas)
+	}
+
+	return limits
+}
+
 func (d *Diagnostic) ErrorIf(condition bool, message string, err error) bool {
 	if condition {
 		d.Errors = append(d.Errors, Error{
@@ -134,9 +178,19 @@ func (d *Diagnostic) WarningIf(condition bool, message string, advice string) bo
 	return !condition
 }
 
-func (d *Diagnostic) diagnoseRegistry(registry types.AuthConfig) {
-	client, err := runtime.NewClient()
+func (d *Diagnostic) diagnoseRegistry(service *config.Service) {
+	registry, err := runtime.ResolveAuth(service.Image, service.Registry, service.CredentialHelper)
 	ok := d.ErrorIf(
+		err != nil,
+		fmt.Sprintf("Failed to read the credentials of the registry of service %s", service.Name),
+		err,
+	)
+	if !ok || registry == nil {
+		return
+	}
+
+	client, err := runtime.NewClient()
+	ok = d.ErrorIf(
 		err != nil,
 		"Failed to create docker client",
 		err,
@@ -145,7 +199,7 @@ func (d *Diagnostic) diagnoseRegistry(registry types.AuthConfig) {
 		return
 	}
 
-	err = client.RegistryLogin(context.Background(), registry)
+	err = client.RegistryLogin(context.Background(), *registry)
 	d.ErrorIf(
 		err != nil,
 		fmt.Sprintf("Failed to login to registry %s", registry.ServerAddress),
@@ -0,0 +1,30 @@
+package medic
+
+import (
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/metrics"
+	"gotest.tools/v3/assert"
+	"testing"
+)
+
+func TestMemoryLimits(t *testing.T) {
+	tests := []struct {
+		services map[string]*config.Service
+		want     metrics.ByteSize
+	}{
+		{map[string]*config.Service{}, 0},
+		{map[string]*config.Service{
+			"api": {Resources: config.Resources{Memory: 512}},
+			"db":  {Resources: config.Resources{Memory: 1024}, Replicas: 1},
+		}, 1536},
+		// each replica is limited on its own
+		{map[string]*config.Service{
+			"api": {Resources: config.Resources{Memory: 512}, Replicas: 3},
+			"db":  {Resources: config.Resources{Memory: 1024}},
+		}, 2560},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, memoryLimits(test.services), test.want)
+	}
+}
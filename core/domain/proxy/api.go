@@ -1,12 +1,14 @@
 package proxy
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/vite-cloud/vite/core/domain/config"
 	"github.com/vite-cloud/vite/core/domain/deployment"
 	"github.com/vite-cloud/vite/core/domain/locator"
-	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/metrics"
 	"github.com/vite-cloud/vite/core/domain/token"
+	"github.com/vite-cloud/vite/core/domain/webhook"
 	"github.com/vite-cloud/vite/core/static"
 	"io"
 )
@@ -18,41 +20,14 @@ func NewAPI() *gin.Engine {
 
 	router := gin.New()
 	router.Use(gin.Recovery())
-	router.Use(func(context *gin.Context) {
-		username, password, ok := context.Request.BasicAuth()
-		if !ok {
-			context.AbortWithStatusJSON(401, gin.H{
-				"error": "unauthorized",
-			})
-			return
-		}
-
-		if username != "token" {
-			context.AbortWithStatusJSON(401, gin.H{
-				"error": "unauthorized (accepts: token)",
-			})
-			return
-		}
-
-		tokens, err := resource.List[token.Token](token.Store)
-		if err != nil {
-			context.AbortWithStatus(500)
-			return
-		}
 
-		for _, t := range tokens {
-			if t.Value == password {
-				context.Next()
-				return
-			}
-		}
+	// webhooks authenticate using the signature of their payload instead of a token
+	debouncer := webhook.NewDebouncer(webhook.DebounceDelay, deployPush)
+	router.POST(ApiV1Prefix+"/webhooks/:provider", receiveWebhook(debouncer))
 
-		context.AbortWithStatusJSON(401, gin.H{
-			"error": "unauthorized",
-		})
-	})
+	api := router.Group(ApiV1Prefix, authenticate)
 
-	router.GET(ApiV1Prefix+"/version", func(c *gin.Context) {
+	api.GET("/version", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"version":    static.Version,
 			"commit":     static.Commit,
@@ -62,17 +37,40 @@ func NewAPI() *gin.Engine {
 		})
 	})
 
-	router.GET(ApiV1Prefix+"/config", func(c *gin.Context) {
+	api.GET("/config", requireScope(token.ScopeReadConfig), func(c *gin.Context) {
+		reveal := c.Query("reveal") == "true"
+
+		if reveal && !c.MustGet("token").(*token.Token).HasScope(token.ScopeAdmin) {
+			c.AbortWithStatusJSON(403, gin.H{
+				"error": "forbidden (reveal requires the admin scope)",
+			})
+			return
+		}
+
 		conf, err := config.GetUsingDefaultLocator()
 		if err != nil {
 			c.AbortWithStatus(500)
 			return
 		}
 
+		if !reveal {
+			conf = conf.Redacted()
+		}
+
 		c.JSON(200, conf)
 	})
 
-	router.GET(ApiV1Prefix+"/deploy", func(c *gin.Context) {
+	api.GET("/metrics", requireScope(token.ScopeReadMetrics), func(c *gin.Context) {
+		gathered, err := metrics.Gather()
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		c.JSON(200, gathered)
+	})
+
+	api.GET("/deploy", requireScope(token.ScopeDeploy), func(c *gin.Context) {
 		loc, err := locator.LoadFromStore()
 		if err != nil {
 			c.AbortWithStatus(500)
@@ -86,21 +84,73 @@ func NewAPI() *gin.Engine {
 
 		events := make(chan deployment.Event)
 
-		go deployment.Deploy(events, loc)
+		t := c.MustGet("token").(*token.Token)
+
+		go deployment.Deploy(events, loc, deployment.WithTrigger("token:"+t.Label))
 
 		c.Stream(func(w io.Writer) bool {
 			// Stream message to client from message channel
 			if event, ok := <-events; ok {
-				if event.Data == nil {
-					c.SSEvent(event.ID, "")
-				} else {
-					c.SSEvent(event.ID, event.Data)
-				}
+				// events are sent using their versioned JSON encoding
+				c.SSEvent(event.ID, event)
 				return true
 			}
 			return false
 		})
+
+		// The client may disconnect before the deployment finishes, events are still read until the channel
+		// is closed so that the deployment, which holds the deploy lock, is never blocked sending them.
+		go func() {
+			for range events {
+			}
+		}()
 	})
 
 	return router
 }
+
+// authenticate rejects requests without a valid token, passed as the password of the "token" user.
+func authenticate(c *gin.Context) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		c.AbortWithStatusJSON(401, gin.H{
+			"error": "unauthorized",
+		})
+		return
+	}
+
+	if username != "token" {
+		c.AbortWithStatusJSON(401, gin.H{
+			"error": "unauthorized (accepts: token)",
+		})
+		return
+	}
+
+	t, err := token.Authenticate(password)
+	if errors.Is(err, token.ErrInvalidToken) || errors.Is(err, token.ErrExpiredToken) {
+		c.AbortWithStatusJSON(401, gin.H{
+			"error": "unauthorized (" + err.Error() + ")",
+		})
+		return
+	} else if err != nil {
+		c.AbortWithStatus(500)
+		return
+	}
+
+	c.Set("token", t)
+	c.Next()
+}
+
+// requireScope rejects requests whose token was not granted the given scope.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.MustGet("token").(*token.Token).HasScope(scope) {
+			c.AbortWithStatusJSON(403, gin.H{
+				"error": "forbidden (requires the " + scope + " scope)",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/domain/token"
+	"github.com/vite-cloud/vite/core/domain/webhook"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func saveToken(t *testing.T, label string, scopes []string, expiresAt time.Time) string {
+	tok, value, err := token.New(label, scopes, 0)
+	assert.NilError(t, err)
+
+	tok.ExpiresAt = expiresAt
+
+	err = resource.Save[*token.Token](token.Store, tok, func(t *token.Token) string {
+		return t.Label
+	})
+	assert.NilError(t, err)
+
+	return value
+}
+
+func TestNewAPI(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	deployer := saveToken(t, "deployer", []string{token.ScopeDeploy}, time.Time{})
+	reader := saveToken(t, "reader", []string{token.ScopeReadConfig}, time.Time{})
+	expired := saveToken(t, "expired", nil, time.Now().Add(-time.Hour))
+
+	api := NewAPI()
+
+	tests := []struct {
+		path     string
+		password string
+		status   int
+	}{
+		{"/version", "", 401},
+		{"/version", "tok_unknown", 401},
+		{"/version", expired, 401},
+		{"/config", deployer, 403},
+		{"/metrics", reader, 403},
+		{"/deploy", reader, 403},
+		// revealing the config requires the admin scope
+		{"/config?reveal=true", reader, 403},
+		{"/version", deployer, 200},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, ApiV1Prefix+test.path, nil)
+		if test.password != "" {
+			req.SetBasicAuth("token", test.password)
+		}
+
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+
+		assert.Equal(t, rec.Code, test.status, "GET %s", test.path)
+	}
+}
+
+func TestNewAPI2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	api := NewAPI()
+
+	post := func(provider string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodPost, ApiV1Prefix+"/webhooks/"+provider, strings.NewReader("{}"))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	// webhooks are disabled until their secret is set
+	assert.Equal(t, post("gitlab", nil), 404)
+
+	assert.NilError(t, secret.Set(webhook.SecretName, "secret"))
+	assert.NilError(t, (&locator.Locator{Branch: "main"}).Save())
+
+	assert.Equal(t, post("gitea", nil), 404)
+	assert.Equal(t, post("gitlab", http.Header{"X-Gitlab-Token": {"other"}}), 401)
+	// webhooks do not require a token
+	assert.Equal(t, post("gitlab", http.Header{"X-Gitlab-Token": {"secret"}, "X-Gitlab-Event": {"Issue Hook"}}), 202)
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"sync"
+
+	"github.com/vite-cloud/vite/core/domain/config"
+)
+
+// balancer spreads the requests to a service across its replicas.
+type balancer struct {
+	mu sync.Mutex
+	// next is the offset of the next replica to consider for each service.
+	next map[string]int
+	// active is the number of requests being served by each replica, by IP.
+	active map[string]int
+}
+
+func newBalancer() *balancer {
+	return &balancer{
+		next:   map[string]int{},
+		active: map[string]int{},
+	}
+}
+
+// Acquire returns the IP of the replica that serves the next request to the service, using its LoadBalancing.
+// Release must be called with the returned IP once the request is served.
+func (b *balancer) Acquire(service *config.Service, ips []string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	start := b.next[service.Name] % len(ips)
+	b.next[service.Name] = start + 1
+
+	ip := ips[start]
+
+	// Replicas are considered from the offset so that ties are broken in turn rather than always by the first one.
+	if service.LoadBalancing == config.LeastConnections {
+		for i := 1; i < len(ips); i++ {
+			candidate := ips[(start+i)%len(ips)]
+			if b.active[candidate] < b.active[ip] {
+				ip = candidate
+			}
+		}
+	}
+
+	b.active[ip]++
+
+	return ip
+}
+
+// Release records that a request acquired for the given IP was served.
+func (b *balancer) Release(ip string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.active[ip]--; b.active[ip] <= 0 {
+		delete(b.active, ip)
+	}
+}
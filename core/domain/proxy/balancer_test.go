@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/vite-cloud/vite/core/domain/config"
+	"gotest.tools/v3/assert"
+)
+
+func TestBalancer_Acquire(t *testing.T) {
+	b := newBalancer()
+	service := &config.Service{Name: "api", LoadBalancing: config.RoundRobin}
+	ips := []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"}
+
+	var picked []string
+	for i := 0; i < 4; i++ {
+		ip := b.Acquire(service, ips)
+		b.Release(ip)
+
+		picked = append(picked, ip)
+	}
+
+	assert.DeepEqual(t, picked, []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.2"})
+	assert.Equal(t, len(b.active), 0)
+}
+
+func TestBalancer_Acquire2(t *testing.T) {
+	b := newBalancer()
+	service := &config.Service{Name: "api", LoadBalancing: config.LeastConnections}
+	ips := []string{"10.0.0.2", "10.0.0.3"}
+
+	// the first replica is kept busy by a slow request
+	slow := b.Acquire(service, ips)
+	assert.Equal(t, slow, "10.0.0.2")
+
+	for i := 0; i < 3; i++ {
+		ip := b.Acquire(service, ips)
+		assert.Equal(t, ip, "10.0.0.3")
+		b.Release(ip)
+	}
+
+	b.Release(slow)
+
+	// ties are broken in turn
+	first := b.Acquire(service, ips)
+	second := b.Acquire(service, ips)
+	assert.Assert(t, first != second)
+}
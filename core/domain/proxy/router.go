@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/vite-cloud/go-zoup"
@@ -11,28 +12,65 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
 	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/runtime"
 )
 
+// DrainTimeout is the maximum duration requests still being served by a previous
+// deployment are given to complete after the router switched to a new one.
+const DrainTimeout = 30 * time.Second
+
+// Router routes incoming requests to the containers of the current deployment.
+// The deployment can be swapped at any time using Use without dropping requests.
 type Router struct {
+	// mu guards the fields below, which are swapped together by Use.
+	mu         sync.RWMutex
 	deployment *deployment.Deployment
-	ips        sync.Map
-	mu         sync.Mutex
-	logger     *Logger
-	API        *gin.Engine
 	config     *config.Config
+	ips        *sync.Map
+	inflight   *sync.WaitGroup
+	transport  *http.Transport
+	// served records that the deployment is served, so that it is not cleaned up, see deployment.InUse.
+	served *datadir.Lock
+	// rolling is the deployment in progress, if any, its ready replicas replace the ones of the current deployment.
+	// Its requests are counted apart so that they are drained before it stops being served, see Roll.
+	rolling         *deployment.Deployment
+	rollingConfig   *config.Config
+	rollingInflight *sync.WaitGroup
+	rollingServed   *datadir.Lock
+
+	docker   *runtime.Client
+	logger   *Logger
+	balancer *balancer
+	API      *gin.Engine
 }
 
 func (r *Router) Proxy(w http.ResponseWriter, req *http.Request) {
-	if req.Host == r.config.ControlPlane.Host {
+	r.mu.RLock()
+	conf, ips, transport, inflight := r.config, r.ips, r.transport, r.inflight
+	dep, rolling, rollingConf, rollingInflight := r.deployment, r.rolling, r.rollingConfig, r.rollingInflight
+	inflight.Add(1)
+	if rollingInflight != nil {
+		rollingInflight.Add(1)
+	}
+	r.mu.RUnlock()
+
+	defer inflight.Done()
+	if rollingInflight != nil {
+		defer rollingInflight.Done()
+	}
+
+	if req.Host == conf.ControlPlane.Host {
 		r.logger.LogR(req, zoup.DebugLevel, "proxy to control plane")
 		r.API.ServeHTTP(w, req)
 		return
 	}
 
-	targetIP, err := r.IPFor(req.Host)
+	service, targetIPs, err := r.ipsFor(dep, conf, rolling, rollingConf, ips, req.Host)
 	if err != nil {
 		w.WriteHeader(http.StatusBadGateway)
 		w.Write([]byte("Bad Gateway"))
@@ -40,51 +78,310 @@ func (r *Router) Proxy(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if targetIP == "" {
+	if len(targetIPs) == 0 {
 		w.WriteHeader(http.StatusBadGateway)
 		w.Write([]byte("Upstream did not respond."))
 		r.logger.LogR(req, zoup.InfoLevel, "host not found")
 		return
 	}
 
-	httputil.NewSingleHostReverseProxy(&url.URL{
+	targetIP := r.balancer.Acquire(service, targetIPs)
+	defer r.balancer.Release(targetIP)
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{
 		Scheme: "http",
 		Host:   targetIP,
-	}).ServeHTTP(w, req)
+	})
+	proxy.Transport = transport
+	proxy.ServeHTTP(w, req)
 
 	r.logger.LogR(req, zoup.InfoLevel, "served")
 }
 
+// IPFor returns the IP of the replica that would serve the given host, using the load balancing of its service.
 func (r *Router) IPFor(host string) (string, error) {
-	if ip, ok := r.ips.Load(host); ok {
-		return ip.(string), nil
+	r.mu.RLock()
+	dep, conf, rolling, rollingConf, ips := r.deployment, r.config, r.rolling, r.rollingConfig, r.ips
+	r.mu.RUnlock()
+
+	service, targetIPs, err := r.ipsFor(dep, conf, rolling, rollingConf, ips, host)
+	if err != nil || len(targetIPs) == 0 {
+		return "", err
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	ip := r.balancer.Acquire(service, targetIPs)
+	r.balancer.Release(ip)
 
-	service, err := r.serviceFor(host)
+	return ip, nil
+}
+
+// ipsFor returns the service serving the given host and the IPs of its replicas.
+// While a deployment is rolling out, each of its ready replicas replaces one of the current deployment.
+// They are found using the config of the rolling deployment, in which the host may be served by another service.
+func (r *Router) ipsFor(dep *deployment.Deployment, conf *config.Config, rolling *deployment.Deployment, rollingConf *config.Config, ips *sync.Map, host string) (*config.Service, []string, error) {
+	service, err := serviceFor(conf, host)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
-	id, err := r.deployment.Find("created_containers", service.Name)
+	if cached, ok := ips.Load(service.Name); ok {
+		return service, cached.([]string), nil
+	}
+
+	ids, err := dep.ContainerIDs(service.Name)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+
+	if rolling != nil {
+		var ready []string
+		if rollingService, err := serviceFor(rollingConf, host); err == nil {
+			ready = rolling.ReadyContainerIDs(rollingService.Name)
+		}
+
+		if len(ready) >= len(ids) {
+			ids = ready
+		} else {
+			ids = append(ready, ids[len(ready):]...)
+		}
+	}
+
+	var targetIPs []string
+
+	for _, id := range ids {
+		ins, err := r.docker.ContainerInspect(context.Background(), id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ins.NetworkSettings.IPAddress != "" {
+			targetIPs = append(targetIPs, ins.NetworkSettings.IPAddress)
+		}
 	}
 
-	ins, err := r.deployment.Docker.ContainerInspect(context.Background(), id.(string))
+	ips.Store(service.Name, targetIPs)
+
+	return service, targetIPs, nil
+}
+
+// Use atomically routes new requests to the given deployment and flushes the IP cache.
+// Requests still being served by the previous deployment are drained in the background.
+func (r *Router) Use(dep *deployment.Deployment, conf *config.Config) {
+	served, err := dep.Serve()
 	if err != nil {
-		return "", err
+		r.logger.Log(zoup.WarnLevel, "could not record the deployment as served", zoup.Fields{
+			"deployment": dep.ID(),
+			"err":        err.Error(),
+		})
+	}
+
+	r.mu.Lock()
+	previous, previousInflight, previousTransport, previousServed := r.deployment, r.inflight, r.transport, r.served
+	rolling, rollingInflight, rollingServed := r.rolling, r.rollingInflight, r.rollingServed
+
+	r.deployment = dep
+	r.served = served
+	r.config = conf
+	r.rolling = nil
+	r.rollingConfig = nil
+	r.rollingInflight = nil
+	r.rollingServed = nil
+	r.ips = &sync.Map{}
+	r.inflight = &sync.WaitGroup{}
+	r.transport = http.DefaultTransport.(*http.Transport).Clone()
+	r.mu.Unlock()
+
+	if rolling != nil {
+		go r.drain(rolling, rollingInflight, nil, rollingServed)
+	}
+
+	if previous == nil {
+		r.logger.Log(zoup.InfoLevel, "using deployment", zoup.Fields{
+			"deployment": dep.ID(),
+		})
+		return
 	}
 
-	r.ips.Store(host, ins.NetworkSettings.IPAddress)
+	r.logger.Log(zoup.InfoLevel, "switched deployment", zoup.Fields{
+		"from": previous.ID(),
+		"to":   dep.ID(),
+	})
 
-	return ins.NetworkSettings.IPAddress, nil
+	go r.drain(previous, previousInflight, previousTransport, previousServed)
 }
 
-func (r *Router) serviceFor(host string) (*config.Service, error) {
-	for _, service := range r.config.Services {
+// Roll routes new requests to the ready replicas of the given deployment in progress, in place of as many
+// replicas of the current deployment. The previous rolling deployment, if any, is replaced and nil stops rolling.
+// Its requests are drained in the background before its served lock is released, see deployment.ServeInProgress.
+func (r *Router) Roll(dep *deployment.Deployment, conf *config.Config, served *datadir.Lock) {
+	r.mu.Lock()
+	previous, previousInflight, previousServed := r.rolling, r.rollingInflight, r.rollingServed
+
+	r.rolling = dep
+	r.rollingConfig = conf
+	r.rollingServed = served
+	r.rollingInflight = nil
+	if dep != nil {
+		r.rollingInflight = &sync.WaitGroup{}
+	}
+	r.ips = &sync.Map{}
+	r.mu.Unlock()
+
+	if previous != nil {
+		go r.drain(previous, previousInflight, nil, previousServed)
+	}
+
+	if dep == nil {
+		r.logger.Log(zoup.InfoLevel, "stopped rolling deployment", zoup.Fields{})
+		return
+	}
+
+	r.logger.Log(zoup.InfoLevel, "rolling deployment", zoup.Fields{
+		"deployment": dep.ID(),
+		"ready":      readyCount(dep),
+	})
+}
+
+// drain waits for the requests served by a previous deployment to complete, closes the idle connections
+// kept open to its containers, if its transport is given, and releases its served lock, if any.
+func (r *Router) drain(dep *deployment.Deployment, inflight *sync.WaitGroup, transport *http.Transport, served *datadir.Lock) {
+	done := make(chan struct{})
+
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+
+	drained := true
+
+	select {
+	case <-done:
+	case <-time.After(DrainTimeout):
+		drained = false
+	}
+
+	if transport != nil {
+		transport.CloseIdleConnections()
+	}
+
+	if served != nil {
+		served.Unlock()
+	}
+
+	r.logger.Log(zoup.InfoLevel, "drained deployment", zoup.Fields{
+		"deployment": dep.ID(),
+		"timeout":    !drained,
+	})
+}
+
+// Current returns the deployment currently in use.
+func (r *Router) Current() *deployment.Deployment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.deployment
+}
+
+// Watch polls the deployment store and switches to the most recent successful
+// deployment when it changes, rolling out the replicas of the deployment in progress
+// as they become ready. It returns when the context is cancelled.
+func (r *Router) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.useLatest(); err != nil {
+				r.logger.Log(zoup.ErrorLevel, "could not switch deployment", zoup.Fields{
+					"err": err.Error(),
+				})
+			}
+
+			if err := r.rollInProgress(); err != nil {
+				r.logger.Log(zoup.ErrorLevel, "could not roll deployment", zoup.Fields{
+					"err": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+// useLatest switches to the most recent successful deployment, if it is not already in use.
+func (r *Router) useLatest() error {
+	latest, err := deployment.Latest()
+	if errors.Is(err, deployment.ErrNoDeployment) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if current := r.Current(); current != nil && current.ID() == latest.ID() {
+		return nil
+	}
+
+	conf, err := config.Get(latest.Locator)
+	if err != nil {
+		return err
+	}
+
+	r.Use(latest, conf)
+
+	return nil
+}
+
+// rollInProgress rolls the deployment in progress, if it is more recent than the current one
+// and more of its replicas are ready since it was last rolled. Rolling stops once it is no longer in progress.
+func (r *Router) rollInProgress() error {
+	r.mu.RLock()
+	current, rolling := r.deployment, r.rolling
+	r.mu.RUnlock()
+
+	dep, err := deployment.InProgress()
+	if errors.Is(err, deployment.ErrNoDeploymentInProgress) || (err == nil && current != nil && !dep.Time().After(current.Time())) {
+		if rolling != nil {
+			r.Roll(nil, nil, nil)
+		}
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if rolling != nil && rolling.ID() == dep.ID() && readyCount(rolling) == readyCount(dep) {
+		return nil
+	}
+
+	conf, err := config.Get(dep.Locator)
+	if err != nil {
+		return err
+	}
+
+	served, err := dep.ServeInProgress()
+	if errors.Is(err, deployment.ErrNoDeploymentInProgress) {
+		if rolling != nil {
+			r.Roll(nil, nil, nil)
+		}
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	r.Roll(dep, conf, served)
+
+	return nil
+}
+
+// readyCount returns the number of replicas of a deployment that are ready.
+func readyCount(dep *deployment.Deployment) int {
+	return len(dep.All()["ready_containers"])
+}
+
+func serviceFor(conf *config.Config, host string) (*config.Service, error) {
+	for _, service := range conf.Services {
 		for _, h := range service.Hosts {
 			if ok, err := hostMatches(host, h); ok {
 				return service, err
@@ -108,8 +405,13 @@ func hostMatches(host string, pattern string) (bool, error) {
 
 	return re.MatchString(host), nil
 }
+
 func (r *Router) Accepts(host string) (bool, error) {
-	_, err := r.serviceFor(host)
+	r.mu.RLock()
+	conf := r.config
+	r.mu.RUnlock()
+
+	_, err := serviceFor(conf, host)
 	if err != nil {
 		return false, err
 	}
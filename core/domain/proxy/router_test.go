@@ -1,8 +1,21 @@
 package proxy
 
 import (
+	"encoding/json"
+	"errors"
+	"github.com/docker/docker/client"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/runtime"
 	"gotest.tools/v3/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 //func TestRouter_IPFor(t *testing.T) {
@@ -69,3 +82,185 @@ func TestHostMatches(t *testing.T) {
 	assert.Assert(t, !ok)
 	assert.Assert(t, err != nil)
 }
+
+func TestRouter_Use(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	router := &Router{logger: &Logger{writer: &zoup.MemoryWriter{}}}
+
+	first := &deployment.Deployment{}
+	router.Use(first, &config.Config{})
+	router.ips.Store("example.com", "10.0.0.2")
+
+	second := &deployment.Deployment{}
+	conf := &config.Config{}
+	router.Use(second, conf)
+
+	assert.Equal(t, router.Current(), second)
+	assert.Equal(t, router.config, conf)
+
+	_, ok := router.ips.Load("example.com")
+	assert.Assert(t, !ok)
+
+	// the deployment is not cleaned up while it is served
+	inUse, err := second.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, inUse)
+}
+
+func TestRouter_Drain(t *testing.T) {
+	logger := &zoup.MemoryWriter{}
+	router := &Router{logger: &Logger{writer: logger}}
+
+	inflight := &sync.WaitGroup{}
+	inflight.Add(1)
+
+	done := make(chan struct{})
+	go func() {
+		router.drain(&deployment.Deployment{}, inflight, &http.Transport{}, nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("drain returned while a request was still in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	inflight.Done()
+	<-done
+
+	assert.Equal(t, logger.Last().Message, "drained deployment")
+	assert.Equal(t, logger.Last().Fields["timeout"], false)
+}
+
+func TestRouter_Roll2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	router := &Router{logger: &Logger{writer: discardWriter{}}}
+
+	rolling := &deployment.Deployment{}
+	served, err := rolling.Serve()
+	assert.NilError(t, err)
+
+	router.Roll(rolling, &config.Config{}, served)
+
+	// a request is being served by the rolling deployment when it stops rolling
+	inflight := router.rollingInflight
+	inflight.Add(1)
+
+	router.Roll(nil, nil, nil)
+
+	time.Sleep(50 * time.Millisecond)
+	inUse, err := rolling.InUse()
+	assert.NilError(t, err)
+	assert.Assert(t, inUse)
+
+	inflight.Done()
+
+	// the rolling deployment is released once the request is served
+	assert.NilError(t, poll(func() (bool, error) {
+		inUse, err := rolling.InUse()
+		return !inUse, err
+	}))
+}
+
+// discardWriter drops logs, unlike zoup.MemoryWriter it may be written to by the drains running in the background.
+type discardWriter struct{}
+
+func (discardWriter) Write(zoup.Level, string, zoup.Fields) error {
+	return nil
+}
+
+// poll calls check until it returns true, an error or a second passed.
+func poll(check func() (bool, error)) error {
+	for i := 0; i < 100; i++ {
+		if ok, err := check(); ok || err != nil {
+			return err
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return errors.New("timed out")
+}
+
+func TestRouter_Roll(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// containers named old-N and new-N have the IP 10.0.0.N and 10.0.1.N
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1.41/containers/"), "/json")
+		name, n, _ := strings.Cut(id, "-")
+
+		ip := "10.0.0." + n
+		if name == "new" {
+			ip = "10.0.1." + n
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"Id":              id,
+			"NetworkSettings": map[string]any{"IPAddress": ip},
+		})
+	}))
+	defer server.Close()
+
+	raw, err := client.NewClientWithOpts(client.WithHost(server.URL))
+	assert.NilError(t, err)
+
+	docker, err := runtime.NewClient(runtime.WithDockerClient(raw))
+	assert.NilError(t, err)
+
+	router := &Router{docker: docker, logger: &Logger{writer: discardWriter{}}, balancer: newBalancer()}
+
+	current := &deployment.Deployment{}
+	for _, id := range []string{"old-1", "old-2", "old-3"} {
+		current.Add("created_containers", "api", id)
+	}
+
+	conf := &config.Config{Services: map[string]*config.Service{
+		"api": {Name: "api", Hosts: []string{"example.com"}, Replicas: 3},
+	}}
+	router.Use(current, conf)
+
+	ips := func() []string {
+		_, ips, err := router.ipsFor(router.deployment, router.config, router.rolling, router.rollingConfig, router.ips, "example.com")
+		assert.NilError(t, err)
+
+		return ips
+	}
+
+	assert.DeepEqual(t, ips(), []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+
+	// each ready replica of the deployment in progress replaces one of the current deployment
+	rolling := &deployment.Deployment{}
+	rolling.Add("ready_containers", "api", "new-1")
+	router.Roll(rolling, conf, nil)
+
+	assert.DeepEqual(t, ips(), []string{"10.0.1.1", "10.0.0.2", "10.0.0.3"})
+
+	rolling.Add("ready_containers", "api", "new-2")
+	rolling.Add("ready_containers", "api", "new-3")
+	router.Roll(rolling, conf, nil)
+
+	assert.DeepEqual(t, ips(), []string{"10.0.1.1", "10.0.1.2", "10.0.1.3"})
+
+	// a failed deployment stops rolling, the current deployment serves every request again
+	router.Roll(nil, nil, nil)
+
+	assert.DeepEqual(t, ips(), []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"})
+
+	// the replicas of the deployment in progress are found using its own config
+	renamed := &deployment.Deployment{}
+	renamed.Add("ready_containers", "web", "new-1")
+	router.Roll(renamed, &config.Config{Services: map[string]*config.Service{
+		"web": {Name: "web", Hosts: []string{"example.com"}, Replicas: 3},
+	}}, nil)
+
+	assert.DeepEqual(t, ips(), []string{"10.0.1.1", "10.0.0.2", "10.0.0.3"})
+
+	router.Roll(rolling, conf, nil)
+	router.Use(&deployment.Deployment{}, conf)
+	assert.Assert(t, router.rolling == nil && router.rollingConfig == nil)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/vite-cloud/vite/core/domain/datadir"
 	"github.com/vite-cloud/vite/core/domain/deployment"
 	"github.com/vite-cloud/vite/core/domain/log"
+	"github.com/vite-cloud/vite/core/domain/runtime"
 	"golang.org/x/crypto/acme/autocert"
 	"io"
 	"net"
@@ -52,7 +53,13 @@ func New(stdout io.Writer, deployment *deployment.Deployment) (*Proxy, error) {
 		return nil, err
 	}
 
-	router := &Router{deployment: deployment, logger: l, API: NewAPI(), config: conf}
+	docker, err := runtime.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	router := &Router{docker: docker, logger: l, balancer: newBalancer(), API: NewAPI()}
+	router.Use(deployment, conf)
 
 	return &Proxy{
 		Router: router,
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/domain/log"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/domain/webhook"
+	"io"
+)
+
+// maxWebhookSize is the maximum size of a webhook payload, GitHub caps them at 25MB.
+const maxWebhookSize = 25 << 20
+
+// receiveWebhook schedules the deployment of pushes to the branch of the locator.
+func receiveWebhook(debouncer *webhook.Debouncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := secret.Get(webhook.SecretName)
+		if errors.Is(err, secret.ErrNotFound) {
+			c.AbortWithStatusJSON(404, gin.H{
+				"error": "webhooks are disabled (run `vite secrets set " + webhook.SecretName + "` to enable them)",
+			})
+			return
+		} else if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+		if err != nil {
+			c.AbortWithStatus(400)
+			return
+		}
+
+		push, err := webhook.Receive(c.Param("provider"), c.Request.Header, body, key)
+		if errors.Is(err, webhook.ErrUnsupportedProvider) {
+			c.AbortWithStatusJSON(404, gin.H{
+				"error": err.Error(),
+			})
+			return
+		} else if errors.Is(err, webhook.ErrInvalidSignature) {
+			c.AbortWithStatusJSON(401, gin.H{
+				"error": "unauthorized (" + err.Error() + ")",
+			})
+			return
+		} else if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{
+				"error": "invalid payload",
+			})
+			return
+		}
+
+		loc, err := locator.LoadFromStore()
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+
+		if push == nil || push.Branch != loc.Branch {
+			c.JSON(202, gin.H{
+				"status": "ignored",
+			})
+			return
+		}
+
+		debouncer.Push(*push)
+
+		c.JSON(202, gin.H{
+			"status": "scheduled",
+			"commit": push.Commit,
+		})
+	}
+}
+
+// deployPush sets the commit of the locator to the pushed one and deploys it.
+// A deployment started from the CLI or the API may be running, the push is then deployed once it finishes.
+func deployPush(push webhook.Push) {
+	fields := zoup.Fields{
+		"provider": push.Provider,
+		"branch":   push.Branch,
+		"commit":   push.Commit,
+	}
+
+	// The commit is only saved once no other deployment may pick it up.
+	lock, err := deployment.WaitForDeployments()
+	if err != nil {
+		fields["error"] = err
+		log.Log(zoup.ErrorLevel, "could not deploy push", fields)
+		return
+	}
+
+	// the lock is released by the deployment once it is over
+	deployed := false
+	defer func() {
+		if !deployed {
+			lock.Unlock()
+		}
+	}()
+
+	loc, err := locator.LoadFromStore()
+	if err != nil {
+		fields["error"] = err
+		log.Log(zoup.ErrorLevel, "could not deploy push", fields)
+		return
+	}
+
+	// the branch may have changed while the push was debounced
+	if push.Branch != loc.Branch {
+		return
+	}
+
+	commit, err := loc.ResolveCommit(push.Commit)
+	if err != nil {
+		fields["error"] = err
+		log.Log(zoup.ErrorLevel, "could not deploy push", fields)
+		return
+	}
+
+	loc.Commit = commit
+
+	if err = loc.Save(); err != nil {
+		fields["error"] = err
+		log.Log(zoup.ErrorLevel, "could not deploy push", fields)
+		return
+	}
+
+	events := make(chan deployment.Event)
+
+	go deployment.Deploy(events, loc, deployment.WithTrigger("webhook:"+push.Provider), deployment.WithLock(lock))
+	deployed = true
+
+	failed := false
+
+	for event := range events {
+		if event.IsError() {
+			failed = true
+			fields["error"] = event.String()
+		}
+	}
+
+	if failed {
+		log.Log(zoup.ErrorLevel, "deployment of push failed", fields)
+		return
+	}
+
+	log.Log(zoup.InfoLevel, "deployed push", fields)
+}
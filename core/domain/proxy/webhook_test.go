@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/webhook"
+	"gotest.tools/v3/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeployPush(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "vite.yaml"), []byte("services: {}\n"), 0600))
+
+	loc, err := locator.NewLocal(dir, "")
+	assert.NilError(t, err)
+	assert.NilError(t, loc.Save())
+
+	// a deployment is started from the CLI before the push is deployed
+	lock, err := deployment.LockDeployments()
+	assert.NilError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		deployPush(webhook.Push{Provider: "github", Commit: loc.Commit})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("push deployed while another deployment was running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NilError(t, lock.Unlock())
+	<-done
+
+	deployments, err := resource.List[deployment.Deployment](deployment.Store)
+	assert.NilError(t, err)
+	assert.Equal(t, len(deployments), 1)
+	assert.Equal(t, deployments[0].Trigger, "webhook:github")
+
+	events, err := deployment.ReadEvents(deployments[0].ID())
+	assert.NilError(t, err)
+
+	for _, event := range events {
+		assert.Assert(t, !event.IsError(), event.String())
+	}
+}
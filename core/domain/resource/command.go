@@ -1,9 +1,11 @@
 package resource
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/vite-cloud/vite/core/domain/datadir"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"io"
 	"sort"
 	"time"
 )
@@ -13,11 +15,23 @@ type resource interface {
 	Time() time.Time
 }
 
+// summarizer is implemented by resources that have a short description to display in lists.
+type summarizer interface {
+	Summary() string
+}
+
+// describer is implemented by resources that have a detailed description to display.
+type describer interface {
+	Describe(w io.Writer)
+}
+
 type Manager[T resource] struct {
 	Store datadir.Store
 }
 
-func (m Manager[T]) ListCommand(cli *cli.CLI) error {
+// ListCommand prints the resources of the store, the most recent first.
+// If asJSON is true, resources are printed as a JSON array instead.
+func (m Manager[T]) ListCommand(cli *cli.CLI, asJSON bool) error {
 	// todo: pagination
 	deps, err := List[T](m.Store)
 	if err != nil {
@@ -28,7 +42,16 @@ func (m Manager[T]) ListCommand(cli *cli.CLI) error {
 		return (*deps[i]).Time().After((*deps[j]).Time())
 	})
 
+	if asJSON {
+		return printJSON(cli, deps)
+	}
+
 	for _, dep := range deps {
+		if s, ok := any(*dep).(summarizer); ok {
+			fmt.Fprintf(cli.Out(), "- %s | %s | %s\n", (*dep).ID(), fmtTime((*dep).Time()), s.Summary())
+			continue
+		}
+
 		fmt.Fprintf(cli.Out(), "- %s | %s\n", (*dep).ID(), fmtTime((*dep).Time()))
 	}
 
@@ -37,16 +60,33 @@ func (m Manager[T]) ListCommand(cli *cli.CLI) error {
 	return nil
 }
 
-func (m Manager[T]) ShowCommand(cli *cli.CLI, ID string) error {
+// ShowCommand prints a given resource.
+// If asJSON is true, the resource is printed as JSON instead.
+func (m Manager[T]) ShowCommand(cli *cli.CLI, ID string, asJSON bool) error {
 	dep, err := Get[T](m.Store, ID)
 	if err != nil {
 		return err
 	}
 
+	if asJSON {
+		return printJSON(cli, dep)
+	}
+
+	if d, ok := any(*dep).(describer); ok {
+		d.Describe(cli.Out())
+		return nil
+	}
+
 	fmt.Fprintf(cli.Out(), "%+v", dep)
-	
+
 	return nil
+}
+
+func printJSON(cli *cli.CLI, v any) error {
+	encoder := json.NewEncoder(cli.Out())
+	encoder.SetIndent("", "  ")
 
+	return encoder.Encode(v)
 }
 
 func fmtTime(t time.Time) any {
@@ -6,20 +6,26 @@ import (
 	"fmt"
 	"github.com/vite-cloud/vite/core/domain/datadir"
 	"os"
+	"path/filepath"
 )
 
+// storeLock is the name of the lock held while a store is written to.
+const storeLock = "store"
+
+// Save writes the result to the Store, replacing any previous version atomically.
 func Save[T any](store datadir.Store, result T, name func(T) string) error {
-	dir, err := store.Dir()
+	contents, err := json.Marshal(result)
 	if err != nil {
 		return err
 	}
 
-	contents, err := json.Marshal(result)
+	lock, err := store.Lock(storeLock)
 	if err != nil {
 		return err
 	}
+	defer lock.Unlock()
 
-	return os.WriteFile(fmt.Sprintf("%s/%s.json", dir, name(result)), contents, 0644)
+	return store.WriteFile(name(result)+".json", contents, 0644)
 }
 
 // List returns a list of all the results in the store.
@@ -41,6 +47,11 @@ func List[T any](store datadir.Store) ([]*T, error) {
 			return nil, fmt.Errorf("manifest store is corrupted: %s is a directory", entry.Name())
 		}
 
+		// The store may contain other files such as locks.
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
 		f, err := store.Open(entry.Name(), os.O_RDONLY, 0)
 		if err != nil {
 			return nil, err
@@ -71,6 +82,12 @@ func Delete[T any](store datadir.Store, entry T, name func(T) string) error {
 
 	path := fmt.Sprintf("%s/%v.json", dir, name(entry))
 
+	lock, err := store.Lock(storeLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
 		return nil
 	} else if err != nil {
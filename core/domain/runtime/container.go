@@ -1,13 +1,18 @@
 package runtime
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/vite-cloud/go-zoup"
 	"github.com/vite-cloud/vite/core/domain/log"
+	"strconv"
+	"strings"
 )
 
 // resource tags available in the manifest
@@ -30,27 +35,48 @@ type ContainerCreateOptions struct {
 	Labels map[string]string
 
 	Networking *network.NetworkingConfig
+
+	// Healthcheck overrides the healthcheck of the image, if set.
+	Healthcheck *container.HealthConfig
+
+	// Resources limits the resources available to the container.
+	Resources container.Resources
+	// RestartPolicy defaults to always.
+	RestartPolicy container.RestartPolicy
+
+	// Mounts are the volumes and bind mounts of the container.
+	Mounts []mount.Mount
 }
 
-// fullImageName returns the full image name, including registry if any
+// fullImageName returns the full image name, including registry if any.
+// Images that already include the host of their registry are returned as is.
 func fullImageName(image string, registry *types.AuthConfig) string {
-	if registry == nil {
+	if registry == nil || hasRegistryHost(image) {
+		return image
+	}
+
+	host := strings.TrimPrefix(registry.ServerAddress, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimSuffix(host, "/")
+
+	if host == "" {
 		return image
 	}
 
-	return fmt.Sprintf("%s/%s", registry.ServerAddress, image)
+	return fmt.Sprintf("%s/%s", host, image)
 }
 
 // ContainerCreate creates a container
 func (c Client) ContainerCreate(ctx context.Context, image string, opts ContainerCreateOptions) (container.ContainerCreateCreatedBody, error) {
 	res, err := c.client.ContainerCreate(ctx, &container.Config{
-		Image:  fullImageName(image, opts.Registry),
-		Env:    opts.Env,
-		Labels: opts.Labels,
+		Image:       fullImageName(image, opts.Registry),
+		Env:         opts.Env,
+		Labels:      opts.Labels,
+		Healthcheck: opts.Healthcheck,
 	}, &container.HostConfig{
-		RestartPolicy: container.RestartPolicy{
-			Name: "always",
-		},
+		RestartPolicy: restartPolicy(opts.RestartPolicy),
+		Resources:     opts.Resources,
+		Mounts:        opts.Mounts,
 	}, opts.Networking, nil, opts.Name)
 	if err != nil {
 		return container.ContainerCreateCreatedBody{}, err
@@ -65,6 +91,15 @@ func (c Client) ContainerCreate(ctx context.Context, image string, opts Containe
 	return res, nil
 }
 
+// restartPolicy returns the given policy or always if it is empty.
+func restartPolicy(policy container.RestartPolicy) container.RestartPolicy {
+	if policy.Name == "" {
+		return container.RestartPolicy{Name: "always"}
+	}
+
+	return policy
+}
+
 // ContainerStart starts a container
 func (c Client) ContainerStart(ctx context.Context, ID string) error {
 	err := c.client.ContainerStart(ctx, ID, types.ContainerStartOptions{})
@@ -93,11 +128,11 @@ func (c Client) ContainerStop(ctx context.Context, ID string) error {
 	return nil
 }
 
-// ContainerRemove removes a container
+// ContainerRemove removes a container.
+// Volumes are kept as they hold data that must outlive deployments.
 func (c Client) ContainerRemove(ctx context.Context, ID string) error {
 	err := c.client.ContainerRemove(ctx, ID, types.ContainerRemoveOptions{
-		RemoveVolumes: true,
-		Force:         true,
+		Force: true,
 	})
 	if err != nil {
 		return err
@@ -131,6 +166,74 @@ func (c Client) ContainerExec(ctx context.Context, ID string, command string) er
 	return nil
 }
 
+// ContainerRun runs a command in a new container created from the given image
+// and removes the container once the command exited.
+func (c Client) ContainerRun(ctx context.Context, image string, command string, opts ContainerCreateOptions) error {
+	res, err := c.client.ContainerCreate(ctx, &container.Config{
+		Image:      fullImageName(image, opts.Registry),
+		Env:        opts.Env,
+		Labels:     opts.Labels,
+		Entrypoint: []string{"sh", "-c"},
+		Cmd:        []string{command},
+	}, &container.HostConfig{}, opts.Networking, nil, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	defer c.ContainerRemove(ctx, res.ID)
+
+	statusCh, errCh := c.client.ContainerWait(ctx, res.ID, container.WaitConditionNextExit)
+
+	if err = c.ContainerStart(ctx, res.ID); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-errCh:
+		return err
+	case status := <-statusCh:
+		log.Log(zoup.DebugLevel, "ran command in container", zoup.Fields{
+			"id":      res.ID,
+			"image":   image,
+			"command": command,
+			"code":    status.StatusCode,
+		})
+
+		if status.StatusCode != 0 {
+			return fmt.Errorf("command %q exited with code %d", command, status.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+// ContainerLogs returns the last lines written by a container to stdout and stderr.
+func (c Client) ContainerLogs(ctx context.Context, ID string, lines int) ([]string, error) {
+	reader, err := c.client.ContainerLogs(ctx, ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	// Containers are not attached to a TTY, so stdout and stderr are multiplexed.
+	var buf bytes.Buffer
+	if _, err = stdcopy.StdCopy(&buf, &buf, reader); err != nil {
+		return nil, err
+	}
+
+	output := strings.TrimRight(buf.String(), "\n")
+	if output == "" {
+		return nil, nil
+	}
+
+	return strings.Split(output, "\n"), nil
+}
+
 func (c Client) ContainerInspect(ctx context.Context, ID string) (types.ContainerJSON, error) {
 	return c.client.ContainerInspect(ctx, ID)
 }
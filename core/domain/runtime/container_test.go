@@ -47,6 +47,10 @@ func TestClient(t *testing.T) {
 			name: "it can remove containers",
 			test: testContainerRemove,
 		},
+		{
+			name: "it can read container logs",
+			test: testContainerLogs,
+		},
 	}
 
 	cli, err := NewClient()
@@ -226,3 +230,20 @@ func testContainerRemove(tc *testCtx) {
 	//assert.Equal(tc.t, len(removed), 1)
 	//assert.Equal(tc.t, removed[0].(string), body.id)
 }
+
+func testContainerLogs(tc *testCtx) {
+	body, err := tc.cli.ContainerCreate(tc.ctx, testImage, ContainerCreateOptions{
+		Name: tc.containerName,
+	})
+	assert.NilError(tc.t, err)
+
+	err = tc.cli.ContainerStart(tc.ctx, body.ID)
+	assert.NilError(tc.t, err)
+
+	// the image's entrypoint logs a few lines on startup
+	time.Sleep(time.Second)
+
+	lines, err := tc.cli.ContainerLogs(tc.ctx, body.ID, 2)
+	assert.NilError(tc.t, err)
+	assert.Equal(tc.t, len(lines), 2)
+}
@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// DockerHubRegistry is the key of Docker Hub in the Docker CLI config.
+const DockerHubRegistry = "https://index.docker.io/v1/"
+
+// credentialsNotFound is printed by credential helpers when they have no credentials for a registry.
+const credentialsNotFound = "credentials not found in native keychain"
+
+// identityTokenUsername is the username returned by credential helpers along with an identity token.
+const identityTokenUsername = "<token>"
+
+// credentialHelperRegex matches the names of credential helpers, run as docker-credential-<name>.
+var credentialHelperRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// dockerConfig is the part of the Docker CLI config, ~/.docker/config.json, holding registry credentials.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	// CredentialsStore is the credential helper used for every registry without a specific one.
+	CredentialsStore string `json:"credsStore"`
+	// CredentialHelpers maps registries to the credential helper providing their credentials.
+	CredentialHelpers map[string]string `json:"credHelpers"`
+}
+
+// DockerConfigPath returns the path of the Docker CLI config, in $DOCKER_CONFIG or ~/.docker.
+func DockerConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// ResolveAuth returns the credentials to pull the given image with.
+// Credentials set in auth are used as is. Otherwise, they are read from the given credential helper, if any,
+// then from the Docker CLI config: its credential helpers, credential store and auths, in that order.
+// nil is returned if no credentials are found, the image is then pulled anonymously.
+func ResolveAuth(image string, auth *types.AuthConfig, helper string) (*types.AuthConfig, error) {
+	if hasCredentials(auth) {
+		return auth, nil
+	}
+
+	host := registryHost(fullImageName(image, auth))
+
+	if helper != "" {
+		return helperAuth(helper, host)
+	}
+
+	config, err := readDockerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if helper = config.CredentialHelpers[host]; helper != "" {
+		return helperAuth(helper, host)
+	}
+
+	if config.CredentialsStore != "" {
+		resolved, err := helperAuth(config.CredentialsStore, host)
+		if resolved != nil || err != nil {
+			return resolved, err
+		}
+	}
+
+	entry, ok := config.Auths[host]
+	if !ok {
+		return nil, nil
+	}
+
+	resolved := &types.AuthConfig{ServerAddress: host, IdentityToken: entry.IdentityToken}
+
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth for %s in the docker config: %w", host, err)
+		}
+
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid auth for %s in the docker config: expected username:password", host)
+		}
+
+		resolved.Username, resolved.Password = username, password
+	}
+
+	return resolved, nil
+}
+
+// hasCredentials returns true if the given auth can be used to authenticate on its own.
+func hasCredentials(auth *types.AuthConfig) bool {
+	return auth != nil && (auth.Username != "" || auth.Password != "" || auth.Auth != "" || auth.IdentityToken != "" || auth.RegistryToken != "")
+}
+
+// readDockerConfig reads the Docker CLI config, an empty config is returned if it does not exist.
+func readDockerConfig() (*dockerConfig, error) {
+	config := &dockerConfig{}
+
+	path, err := DockerConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// helperAuth reads the credentials of the given registry from the docker-credential-<helper> program.
+// nil is returned if the helper has no credentials for the registry.
+// The helper is checked first as it may come from the Docker CLI config as well as from vite's.
+func helperAuth(helper, host string) (*types.AuthConfig, error) {
+	if !credentialHelperRegex.MatchString(helper) || strings.Contains(helper, "..") {
+		return nil, fmt.Errorf("invalid credential helper %q, expected letters, digits, _, . or -", helper)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// helpers print errors on stdout
+		message := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(message, credentialsNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("credential helper %s failed for %s: %w: %s", helper, host, err, message)
+	}
+
+	var credentials struct {
+		Username string
+		Secret   string
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &credentials); err != nil {
+		return nil, fmt.Errorf("credential helper %s returned invalid credentials for %s: %w", helper, host, err)
+	}
+
+	if credentials.Username == identityTokenUsername {
+		return &types.AuthConfig{ServerAddress: host, IdentityToken: credentials.Secret}, nil
+	}
+
+	return &types.AuthConfig{ServerAddress: host, Username: credentials.Username, Password: credentials.Secret}, nil
+}
+
+// registryHost returns the host of the registry the given image is pulled from.
+func registryHost(image string) string {
+	if !hasRegistryHost(image) {
+		return DockerHubRegistry
+	}
+
+	host, _, _ := strings.Cut(image, "/")
+	if host == "docker.io" || host == "index.docker.io" {
+		return DockerHubRegistry
+	}
+
+	return host
+}
+
+// hasRegistryHost returns true if the given image includes the host of its registry.
+// As in Docker, the first component of the image is a host if it contains a dot or a port, or is localhost.
+func hasRegistryHost(image string) bool {
+	first, _, found := strings.Cut(image, "/")
+
+	return found && (strings.ContainsAny(first, ".:") || first == "localhost")
+}
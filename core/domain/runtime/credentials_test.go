@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestFullImageName(t *testing.T) {
+	registry := &types.AuthConfig{ServerAddress: "registry.example.com"}
+
+	assert.Equal(t, fullImageName("app:1.0", nil), "app:1.0")
+	assert.Equal(t, fullImageName("app:1.0", registry), "registry.example.com/app:1.0")
+	assert.Equal(t, fullImageName("team/app:1.0", registry), "registry.example.com/team/app:1.0")
+	assert.Equal(t, fullImageName("registry.example.com/app:1.0", registry), "registry.example.com/app:1.0")
+	assert.Equal(t, fullImageName("localhost:5000/app:1.0", registry), "localhost:5000/app:1.0")
+	assert.Equal(t, fullImageName("app:1.0", &types.AuthConfig{ServerAddress: "https://registry.example.com/"}), "registry.example.com/app:1.0")
+	assert.Equal(t, fullImageName("app:1.0", &types.AuthConfig{Username: "me"}), "app:1.0")
+}
+
+func TestRegistryHost(t *testing.T) {
+	assert.Equal(t, registryHost("nginx:1.21.5"), DockerHubRegistry)
+	assert.Equal(t, registryHost("library/nginx:1.21.5"), DockerHubRegistry)
+	assert.Equal(t, registryHost("docker.io/library/nginx:1.21.5"), DockerHubRegistry)
+	assert.Equal(t, registryHost("ghcr.io/vite-cloud/app:1.0"), "ghcr.io")
+	assert.Equal(t, registryHost("localhost/app:1.0"), "localhost")
+	assert.Equal(t, registryHost("registry:5000/app:1.0"), "registry:5000")
+}
+
+func TestResolveAuth(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	// without a docker config, images are pulled anonymously
+	auth, err := ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, "")
+	assert.NilError(t, err)
+	assert.Assert(t, auth == nil)
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("me:secret"))
+	err = os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths": {"ghcr.io": {"auth": "`+encoded+`"}}}`), 0600)
+	assert.NilError(t, err)
+
+	auth, err = ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, "")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, auth, &types.AuthConfig{ServerAddress: "ghcr.io", Username: "me", Password: "secret"})
+
+	// the registry of the service is used to find the host of the image
+	auth, err = ResolveAuth("vite-cloud/app:1.0", &types.AuthConfig{ServerAddress: "ghcr.io"}, "")
+	assert.NilError(t, err)
+	assert.Equal(t, auth.Username, "me")
+
+	// credentials set in the config are used as is
+	explicit := &types.AuthConfig{ServerAddress: "ghcr.io", Username: "other", Password: "password"}
+	auth, err = ResolveAuth("ghcr.io/vite-cloud/app:1.0", explicit, "")
+	assert.NilError(t, err)
+	assert.Equal(t, auth, explicit)
+
+	auth, err = ResolveAuth("registry.example.com/app:1.0", nil, "")
+	assert.NilError(t, err)
+	assert.Assert(t, auth == nil)
+}
+
+func TestResolveAuth2(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	helper := `#!/bin/sh
+read host
+case "$host" in
+  ghcr.io) echo '{"ServerURL": "ghcr.io", "Username": "me", "Secret": "secret"}' ;;
+  registry.example.com) echo '{"ServerURL": "registry.example.com", "Username": "<token>", "Secret": "token"}' ;;
+  *) echo "credentials not found in native keychain"; exit 1 ;;
+esac
+`
+	err := os.WriteFile(filepath.Join(dir, "docker-credential-test"), []byte(helper), 0700)
+	assert.NilError(t, err)
+
+	auth, err := ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, "test")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, auth, &types.AuthConfig{ServerAddress: "ghcr.io", Username: "me", Password: "secret"})
+
+	auth, err = ResolveAuth("registry.example.com/app:1.0", nil, "test")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, auth, &types.AuthConfig{ServerAddress: "registry.example.com", IdentityToken: "token"})
+
+	auth, err = ResolveAuth("nginx:1.21.5", nil, "test")
+	assert.NilError(t, err)
+	assert.Assert(t, auth == nil)
+
+	_, err = ResolveAuth("nginx:1.21.5", nil, "missing")
+	assert.ErrorContains(t, err, "credential helper missing failed")
+
+	// helpers may also be configured in the docker config
+	err = os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"credHelpers": {"ghcr.io": "test"}}`), 0600)
+	assert.NilError(t, err)
+
+	auth, err = ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, "")
+	assert.NilError(t, err)
+	assert.Equal(t, auth.Password, "secret")
+
+	err = os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"credsStore": "test"}`), 0600)
+	assert.NilError(t, err)
+
+	auth, err = ResolveAuth("registry.example.com/app:1.0", nil, "")
+	assert.NilError(t, err)
+	assert.Equal(t, auth.IdentityToken, "token")
+}
+
+func TestResolveAuth3(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	for _, helper := range []string{"../../bin/sh", "ecr-login/..", "..", "ecr login", "ecr;id"} {
+		_, err := ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, helper)
+		assert.ErrorContains(t, err, "invalid credential helper")
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"credHelpers": {"ghcr.io": "../sh"}}`), 0600)
+	assert.NilError(t, err)
+
+	_, err = ResolveAuth("ghcr.io/vite-cloud/app:1.0", nil, "")
+	assert.ErrorContains(t, err, `invalid credential helper "../sh"`)
+}
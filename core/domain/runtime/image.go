@@ -14,6 +14,9 @@ import (
 type ImagePullOptions struct {
 	// Auth is the authentication settings for pulling the image on a custom registry.
 	Auth *types.AuthConfig
+	// CredentialHelper is the name of the docker-credential-* program providing the credentials of the registry,
+	// it is only used if Auth has no credentials. See ResolveAuth.
+	CredentialHelper string
 	// Listener is an optional progress listener.
 	// It gets called every time, the daemon sends a progress event.
 	Listener func(status string)
@@ -35,7 +38,14 @@ func marshalAuth(auth *types.AuthConfig) (string, error) {
 func (c Client) ImagePull(ctx context.Context, image string, options ImagePullOptions) error {
 	opts := types.ImagePullOptions{}
 
-	auth, err := marshalAuth(options.Auth)
+	image = fullImageName(image, options.Auth)
+
+	resolved, err := ResolveAuth(image, options.Auth, options.CredentialHelper)
+	if err != nil {
+		return err
+	}
+
+	auth, err := marshalAuth(resolved)
 	if err != nil {
 		return err
 	}
@@ -49,7 +59,7 @@ func (c Client) ImagePull(ctx context.Context, image string, options ImagePullOp
 
 	log.Log(zoup.DebugLevel, "pulling docker image", zoup.Fields{
 		"image":     image,
-		"with_auth": options.Auth != nil,
+		"with_auth": resolved != nil,
 	})
 
 	decoder := json.NewDecoder(events)
@@ -58,3 +58,8 @@ func (c Client) NetworkConnect(ctx context.Context, networkID, containerID strin
 
 	return nil
 }
+
+// NetworkList returns the list of networks matching the given options.
+func (c Client) NetworkList(ctx context.Context, opts types.NetworkListOptions) ([]types.NetworkResource, error) {
+	return c.client.NetworkList(ctx, opts)
+}
@@ -2,14 +2,16 @@ package runtime
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"github.com/docker/docker/api/types"
 	"github.com/vite-cloud/go-zoup"
-	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/c-robinson/iplib"
 	"github.com/vite-cloud/vite/core/domain/datadir"
@@ -18,9 +20,10 @@ import (
 
 // subnetManager handles all subnet related operations
 type subnetManager struct {
-	// mu ensures that we
+	// mu ensures that the data file is not accessed concurrently by the manager,
+	// the data file is also locked to prevent concurrent access from other processes.
 	mu     *sync.Mutex
-	used   *os.File
+	path   string
 	blocks []iplib.Net4
 }
 
@@ -38,6 +41,9 @@ const Store = datadir.Store("subnets")
 // SubnetDataFile is the name of the file that stores created subnets.
 const SubnetDataFile = "subnet.dat"
 
+// SubnetLabel is the label set on networks created by vite, it contains the network's subnet.
+const SubnetLabel = "cloud.vite.subnet"
+
 // DefaultSubnetBlocks is the list of private ipv4 blocks.
 // It respects https://datatracker.ietf.org/doc/html/rfc1918.
 // It contains the following blocks:
@@ -52,14 +58,25 @@ var DefaultSubnetBlocks = []iplib.Net4{
 
 // NewSubnetManager creates a new subnet manager.
 func NewSubnetManager() (*subnetManager, error) {
-	file, err := Store.Open(SubnetDataFile, os.O_RDWR|os.O_CREATE, 0600)
+	dir, err := Store.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, SubnetDataFile)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = file.Close(); err != nil {
+		return nil, err
+	}
+
 	return &subnetManager{
 		mu:     &sync.Mutex{},
-		used:   file,
+		path:   path,
 		blocks: DefaultSubnetBlocks,
 	}, nil
 }
@@ -72,65 +89,80 @@ func (sm *subnetManager) WithBlocks(blocks []iplib.Net4) *subnetManager {
 
 // Next returns the next available subnet from any of the blocks.
 func (sm *subnetManager) Next() (*iplib.Net4, error) {
-	next := make(chan iplib.Net4)
-	failed := 0
+	unlock, err := sm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-	for _, network := range sm.blocks {
-		go func(network iplib.Net4) {
-			subnets, _ := network.Subnet(24)
+	allocated, err := sm.read()
+	if err != nil {
+		return nil, err
+	}
 
-			for _, subnet := range subnets {
-				ok, _ := sm.IsFree(subnet.String())
+	used := make(map[string]bool, len(allocated))
+	for _, subnet := range allocated {
+		used[subnet] = true
+	}
 
-				if ok {
-					next <- subnet
-					return
-				}
-			}
+	for _, network := range sm.blocks {
+		subnets, _ := network.Subnet(24)
 
-			sm.mu.Lock()
-			failed++
-			sm.mu.Unlock()
-		}(network)
-	}
+		for _, subnet := range subnets {
+			if used[subnet.String()] {
+				continue
+			}
 
-	for {
-		select {
-		case subnet := <-next:
-			if err := sm.Allocate(subnet.String()); err != nil {
+			if err = sm.append(subnet.String()); err != nil {
 				return nil, err
 			}
+
 			return &subnet, nil
-		case <-time.After(time.Millisecond * 50):
-			sm.mu.Lock()
-			if failed == len(sm.blocks) {
-				sm.mu.Unlock()
-				return nil, ErrNoAvailableSubnet
-			}
-			sm.mu.Unlock()
 		}
 	}
+
+	return nil, ErrNoAvailableSubnet
 }
 
 // Allocate allocates a subnet.
 func (sm *subnetManager) Allocate(subnet string) error {
-	ok, err := sm.IsFree(subnet)
+	unlock, err := sm.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	if !ok {
-		return ErrSubnetAlreadyAllocated
+	subnets, err := sm.read()
+	if err != nil {
+		return err
 	}
 
-	sm.mu.Lock()
-	_, err = sm.used.WriteString(fmt.Sprintf("%s\n", subnet))
-	sm.mu.Unlock()
+	for _, cmp := range subnets {
+		if cmp == subnet {
+			return ErrSubnetAlreadyAllocated
+		}
+	}
+
+	return sm.append(subnet)
+}
 
+// append adds a subnet at the end of the data file.
+func (sm *subnetManager) append(subnet string) error {
+	file, err := os.OpenFile(sm.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return err
 	}
 
+	_, err = file.WriteString(fmt.Sprintf("%s\n", subnet))
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
 	log.Log(zoup.DebugLevel, "subnet allocated", zoup.Fields{
 		"subnet": subnet,
 	})
@@ -138,32 +170,144 @@ func (sm *subnetManager) Allocate(subnet string) error {
 	return nil
 }
 
-// IsFree checks if a subnet is free for allocation.
-func (sm *subnetManager) IsFree(subnet string) (bool, error) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+// Release releases a subnet so that it may be allocated again.
+// It does not return an error if the subnet was not allocated.
+func (sm *subnetManager) Release(subnet string) error {
+	unlock, err := sm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	currentOffset, err := sm.used.Seek(0, io.SeekCurrent)
+	return sm.release(subnet)
+}
+
+func (sm *subnetManager) release(subnet string) error {
+	subnets, err := sm.read()
 	if err != nil {
-		return false, err
+		return err
+	}
+
+	var kept []string
+
+	for _, s := range subnets {
+		if s != subnet {
+			kept = append(kept, s)
+		}
 	}
 
-	_, err = sm.used.Seek(0, io.SeekStart)
+	if err = sm.write(kept); err != nil {
+		return err
+	}
+
+	log.Log(zoup.DebugLevel, "subnet released", zoup.Fields{
+		"subnet": subnet,
+	})
+
+	return nil
+}
+
+// Compact rewrites the data file without duplicated, empty or invalid entries.
+func (sm *subnetManager) Compact() error {
+	unlock, err := sm.lock()
 	if err != nil {
-		return false, err
+		return err
+	}
+	defer unlock()
+
+	return sm.compact()
+}
+
+func (sm *subnetManager) compact() error {
+	subnets, err := sm.read()
+	if err != nil {
+		return err
 	}
 
-	defer func(used *os.File, offset int64) {
-		_, err = used.Seek(offset, io.SeekStart)
-		if err != nil {
-			panic(err)
+	seen := make(map[string]bool, len(subnets))
+	var kept []string
+
+	for _, subnet := range subnets {
+		if _, _, err = net.ParseCIDR(subnet); err != nil || seen[subnet] {
+			continue
 		}
-	}(sm.used, currentOffset)
 
-	scanner := bufio.NewScanner(sm.used)
+		seen[subnet] = true
+		kept = append(kept, subnet)
+	}
 
-	for scanner.Scan() {
-		cmp := scanner.Text()
+	return sm.write(kept)
+}
+
+// Reconcile releases the allocated subnets that are not used by any docker network anymore
+// and compacts the data file. It returns the released subnets.
+func (sm *subnetManager) Reconcile(ctx context.Context, docker *Client) ([]string, error) {
+	unlock, err := sm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	networks, err := docker.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+
+	for _, network := range networks {
+		if subnet, ok := network.Labels[SubnetLabel]; ok {
+			used[subnet] = true
+		}
+
+		// Networks created before subnets were labeled are matched
+		// using their IPAM configuration instead.
+		for _, config := range network.IPAM.Config {
+			used[config.Subnet] = true
+		}
+	}
+
+	allocated, err := sm.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var released []string
+
+	for _, subnet := range allocated {
+		if used[subnet] {
+			continue
+		}
+
+		if err = sm.release(subnet); err != nil {
+			return released, err
+		}
+
+		released = append(released, subnet)
+	}
+
+	return released, sm.compact()
+}
+
+// List returns the allocated subnets.
+func (sm *subnetManager) List() ([]string, error) {
+	unlock, err := sm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return sm.read()
+}
+
+// IsFree checks if a subnet is free for allocation.
+func (sm *subnetManager) IsFree(subnet string) (bool, error) {
+	subnets, err := sm.List()
+	if err != nil {
+		return false, err
+	}
+
+	for _, cmp := range subnets {
 		if cmp == subnet {
 			return false, nil
 		}
@@ -171,3 +315,73 @@ func (sm *subnetManager) IsFree(subnet string) (bool, error) {
 
 	return true, nil
 }
+
+// lock prevents the data file from being accessed by other managers,
+// including the ones running in other processes.
+func (sm *subnetManager) lock() (func(), error) {
+	sm.mu.Lock()
+
+	lock, err := Store.Lock(SubnetDataFile)
+	if err != nil {
+		sm.mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		lock.Unlock()
+		sm.mu.Unlock()
+	}, nil
+}
+
+// read returns the subnets listed in the data file.
+func (sm *subnetManager) read() ([]string, error) {
+	file, err := os.Open(sm.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var subnets []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			subnets = append(subnets, line)
+		}
+	}
+
+	return subnets, scanner.Err()
+}
+
+// write replaces the data file with the given subnets.
+// The new content is written to a temporary file first and renamed over the data file,
+// so that the data file is never left half-written.
+func (sm *subnetManager) write(subnets []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(sm.path), SubnetDataFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	for _, subnet := range subnets {
+		if _, err = tmp.WriteString(subnet + "\n"); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), sm.path)
+}
@@ -1,10 +1,15 @@
 package runtime
 
 import (
+	"context"
+	"github.com/docker/docker/api/types/network"
 	"github.com/vite-cloud/go-zoup"
 	"net"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/c-robinson/iplib"
 	"github.com/vite-cloud/vite/core/domain/datadir"
@@ -135,3 +140,109 @@ func TestSubnetManager_Allocate3(t *testing.T) {
 	assert.Assert(t, len(logger.Last().Fields) == 1)
 	assert.Assert(t, logger.Last().Fields["subnet"] == subnet)
 }
+
+func TestSubnetManager_Release(t *testing.T) {
+	log.SetLogger(&zoup.MemoryWriter{})
+
+	datadir.UseTestHome(t)
+
+	manager, err := NewSubnetManager()
+	assert.NilError(t, err)
+
+	err = manager.Allocate("10.0.0.0/24")
+	assert.NilError(t, err)
+	err = manager.Allocate("10.0.1.0/24")
+	assert.NilError(t, err)
+
+	err = manager.Release("10.0.0.0/24")
+	assert.NilError(t, err)
+
+	ok, err := manager.IsFree("10.0.0.0/24")
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+
+	ok, err = manager.IsFree("10.0.1.0/24")
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	// the released subnet can be allocated again
+	err = manager.Allocate("10.0.0.0/24")
+	assert.NilError(t, err)
+
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+	contents, err := os.ReadFile(dir + "/" + SubnetDataFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "10.0.1.0/24\n10.0.0.0/24\n")
+}
+
+func TestSubnetManager_Compact(t *testing.T) {
+	log.SetLogger(&zoup.MemoryWriter{})
+
+	datadir.UseTestHome(t)
+
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+
+	err = os.WriteFile(dir+"/"+SubnetDataFile, []byte("10.0.0.0/24\n\nnot a subnet\n10.0.1.0/24\n10.0.0.0/24\n"), 0600)
+	assert.NilError(t, err)
+
+	manager, err := NewSubnetManager()
+	assert.NilError(t, err)
+
+	err = manager.Compact()
+	assert.NilError(t, err)
+
+	contents, err := os.ReadFile(dir + "/" + SubnetDataFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "10.0.0.0/24\n10.0.1.0/24\n")
+
+	// no temporary file is left behind
+	tmp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	assert.NilError(t, err)
+	assert.Equal(t, len(tmp), 0)
+}
+
+func TestSubnetManager_Reconcile(t *testing.T) {
+	log.SetLogger(&zoup.MemoryWriter{})
+
+	datadir.UseTestHome(t)
+
+	cli, err := NewClient()
+	assert.NilError(t, err)
+
+	ctx := context.Background()
+
+	manager, err := NewSubnetManager()
+	assert.NilError(t, err)
+
+	manager.WithBlocks([]iplib.Net4{
+		iplib.NewNet4(net.IPv4(172, 31, 0, 0), 16),
+	})
+
+	used, err := manager.Next()
+	assert.NilError(t, err)
+
+	unused, err := manager.Next()
+	assert.NilError(t, err)
+
+	name := "test_" + strconv.Itoa(int(time.Now().UnixMilli()))
+
+	id, err := cli.NetworkCreate(ctx, name, NetworkCreateOptions{
+		Labels: map[string]string{SubnetLabel: used.String()},
+		IPAM: &network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: used.String()}},
+		},
+	})
+	assert.NilError(t, err)
+
+	defer cli.NetworkRemove(ctx, id)
+
+	released, err := manager.Reconcile(ctx, cli)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, released, []string{unused.String()})
+
+	subnets, err := manager.List()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, subnets, []string{used.String()})
+}
@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/vite-cloud/go-zoup"
+	"github.com/vite-cloud/vite/core/domain/log"
+)
+
+// VolumeLabel holds the name of a volume in the config, it marks volumes managed by vite.
+const VolumeLabel = "cloud.vite.volume"
+
+// VolumeName returns the name of the Docker volume backing the volume with the given name in the config.
+func VolumeName(name string) string {
+	return "vite_" + name
+}
+
+// VolumeCreate creates a volume managed by vite, it is a no-op if the volume already exists.
+func (c Client) VolumeCreate(ctx context.Context, name string, labels map[string]string) (types.Volume, error) {
+	all := map[string]string{VolumeLabel: name}
+	for k, v := range labels {
+		all[k] = v
+	}
+
+	res, err := c.client.VolumeCreate(ctx, volume.VolumeCreateBody{
+		Name:   VolumeName(name),
+		Labels: all,
+	})
+	if err != nil {
+		return types.Volume{}, err
+	}
+
+	log.Log(zoup.DebugLevel, "created volume", zoup.Fields{
+		"name": res.Name,
+	})
+
+	return res, nil
+}
+
+// VolumeList returns the volumes managed by vite.
+func (c Client) VolumeList(ctx context.Context) ([]*types.Volume, error) {
+	res, err := c.client.VolumeList(ctx, filters.NewArgs(filters.Arg("label", VolumeLabel)))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Volumes, nil
+}
+
+// VolumeInspect returns the volume with the given Docker name.
+func (c Client) VolumeInspect(ctx context.Context, name string) (types.Volume, error) {
+	return c.client.VolumeInspect(ctx, name)
+}
+
+// VolumeRemove removes the volume with the given Docker name.
+// Docker refuses to remove a volume used by a container.
+func (c Client) VolumeRemove(ctx context.Context, name string) error {
+	err := c.client.VolumeRemove(ctx, name, false)
+	if err != nil {
+		return err
+	}
+
+	log.Log(zoup.DebugLevel, "removed volume", zoup.Fields{
+		"name": name,
+	})
+
+	return nil
+}
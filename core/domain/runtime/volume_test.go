@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClient_VolumeCreate(t *testing.T) {
+	cli, err := NewClient()
+	assert.NilError(t, err)
+
+	ctx := context.Background()
+
+	name := "test_" + strconv.Itoa(int(time.Now().UnixMilli()))
+
+	created, err := cli.VolumeCreate(ctx, name, map[string]string{"cloud.vite.service": "db"})
+	assert.NilError(t, err)
+	assert.Equal(t, created.Name, VolumeName(name))
+	assert.Equal(t, created.Labels[VolumeLabel], name)
+
+	// creating an existing volume is a no-op
+	_, err = cli.VolumeCreate(ctx, name, nil)
+	assert.NilError(t, err)
+
+	volumes, err := cli.VolumeList(ctx)
+	assert.NilError(t, err)
+
+	found := false
+	for _, volume := range volumes {
+		found = found || volume.Name == created.Name
+	}
+	assert.Assert(t, found)
+
+	err = cli.VolumeRemove(ctx, created.Name)
+	assert.NilError(t, err)
+}
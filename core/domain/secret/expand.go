@@ -0,0 +1,35 @@
+package secret
+
+import (
+	"regexp"
+)
+
+// referenceRegex matches references to secrets such as ${secret:DB_PASSWORD}, and escaped ones such as $${secret:DB_PASSWORD}.
+var referenceRegex = regexp.MustCompile(`\$(\$\{secret:[^}]*})|\$\{secret:([^}]*)}`)
+
+// Expand replaces the references to secrets in s with their decrypted value.
+// Escaped references, $${secret:NAME}, are replaced with the literal ${secret:NAME}.
+func Expand(s string) (string, error) {
+	var err error
+
+	expanded := referenceRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		groups := referenceRegex.FindStringSubmatch(reference)
+		if groups[1] != "" {
+			return groups[1]
+		}
+
+		if err != nil {
+			return ""
+		}
+
+		var value string
+		value, err = Get(groups[2])
+
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,182 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const Store = datadir.Store("secrets")
+
+// keyFile holds the host key used to encrypt secrets, it never leaves the host.
+const keyFile = "host.key"
+
+var (
+	// ErrNotFound is returned when a secret does not exist.
+	ErrNotFound = errors.New("secret not found")
+	// ErrInvalidName is returned when a secret name contains unsupported characters.
+	ErrInvalidName = errors.New("invalid secret name, only letters, digits, - and _ are allowed")
+	// ErrCorrupted is returned when a secret can not be decrypted with the host key.
+	ErrCorrupted = errors.New("secret can not be decrypted, the host key may have changed")
+)
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Secret is a value encrypted at rest with the host key.
+type Secret struct {
+	Name string
+	// Value is the base64 encoded nonce followed by the AES-GCM sealed value.
+	Value     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (s Secret) ID() string {
+	return s.Name
+}
+
+func (s Secret) Time() time.Time {
+	return s.UpdatedAt
+}
+
+// Set encrypts and stores a secret, replacing any previous value.
+func Set(name, value string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidName, name)
+	}
+
+	aead, err := hostCipher()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+
+	now := time.Now()
+	secret := Secret{
+		Name:      name,
+		Value:     base64.StdEncoding.EncodeToString(sealed),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if previous, err := get(name); err == nil {
+		secret.CreatedAt = previous.CreatedAt
+	}
+
+	return resource.Save[Secret](Store, secret, func(s Secret) string {
+		return s.Name
+	})
+}
+
+// Get returns the decrypted value of a secret.
+func Get(name string) (string, error) {
+	secret, err := get(name)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := hostCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(secret.Value)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("%w: %s", ErrCorrupted, name)
+	}
+
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrCorrupted, name)
+	}
+
+	return string(value), nil
+}
+
+// Remove deletes a secret.
+func Remove(name string) error {
+	secret, err := get(name)
+	if err != nil {
+		return err
+	}
+
+	return resource.Delete[*Secret](Store, secret, func(s *Secret) string {
+		return s.Name
+	})
+}
+
+func get(name string) (*Secret, error) {
+	if !nameRegex.MatchString(name) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidName, name)
+	}
+
+	secret, err := resource.Get[Secret](Store, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	return secret, err
+}
+
+// hostCipher returns the AES-GCM cipher keyed with the host key, generating the key on first use.
+func hostCipher() (cipher.AEAD, error) {
+	key, err := hostKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func hostKey() ([]byte, error) {
+	// Prevents two processes from generating different keys concurrently.
+	lock, err := Store.Lock(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	dir, err := Store.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := os.ReadFile(filepath.Join(dir, keyFile))
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("host key %s is corrupted", keyFile)
+		}
+
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, Store.WriteFile(keyFile, key, 0600)
+}
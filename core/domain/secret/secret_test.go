@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"gotest.tools/v3/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	err := Set("DB_PASSWORD", "hunter2")
+	assert.NilError(t, err)
+
+	value, err := Get("DB_PASSWORD")
+	assert.NilError(t, err)
+	assert.Equal(t, value, "hunter2")
+
+	// the value is encrypted at rest
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dir, "DB_PASSWORD.json"))
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(contents), "hunter2"))
+
+	info, err := os.Stat(filepath.Join(dir, keyFile))
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+
+	// setting a secret again replaces its value
+	err = Set("DB_PASSWORD", "correct horse")
+	assert.NilError(t, err)
+
+	value, err = Get("DB_PASSWORD")
+	assert.NilError(t, err)
+	assert.Equal(t, value, "correct horse")
+
+	secrets, err := resource.List[Secret](Store)
+	assert.NilError(t, err)
+	assert.Equal(t, len(secrets), 1)
+}
+
+func TestSet2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	err := Set("../escape", "value")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	_, err = Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGet(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	err := Set("TOKEN", "value")
+	assert.NilError(t, err)
+
+	// a secret can not be decrypted with another host key
+	dir, err := Store.Dir()
+	assert.NilError(t, err)
+
+	err = os.Remove(filepath.Join(dir, keyFile))
+	assert.NilError(t, err)
+
+	_, err = Get("TOKEN")
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestRemove(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	err := Set("TOKEN", "value")
+	assert.NilError(t, err)
+
+	err = Remove("TOKEN")
+	assert.NilError(t, err)
+
+	_, err = Get("TOKEN")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = Remove("TOKEN")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestExpand(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	err := Set("DB_PASSWORD", "hunter2")
+	assert.NilError(t, err)
+
+	got, err := Expand("DATABASE_URL=postgres://app:${secret:DB_PASSWORD}@db/app")
+	assert.NilError(t, err)
+	assert.Equal(t, got, "DATABASE_URL=postgres://app:hunter2@db/app")
+
+	got, err = Expand("PLAIN=${HOME}")
+	assert.NilError(t, err)
+	assert.Equal(t, got, "PLAIN=${HOME}")
+
+	got, err = Expand("LITERAL=$${secret:DB_PASSWORD}")
+	assert.NilError(t, err)
+	assert.Equal(t, got, "LITERAL=${secret:DB_PASSWORD}")
+
+	_, err = Expand("KEY=${secret:MISSING}")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
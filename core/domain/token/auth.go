@@ -0,0 +1,126 @@
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token does not exist.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned when a token expired.
+	ErrExpiredToken = errors.New("token expired")
+	// ErrUnknownScope is returned when creating a token with a scope that does not exist.
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+// New returns a token and its value, which must be shown to the user as only its hash is stored.
+// The token never expires if ttl is zero, it is granted DefaultScopes if no scopes are given.
+func New(label string, scopes []string, ttl time.Duration) (*Token, string, error) {
+	for _, scope := range scopes {
+		if !isScope(scope) {
+			return nil, "", fmt.Errorf("%w %s, available scopes: %s", ErrUnknownScope, scope, strings.Join(Scopes, ", "))
+		}
+	}
+
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	value := resource.NewIdWithPrefix("tok")
+	now := time.Now()
+
+	t := &Token{
+		Label:     label,
+		Hash:      hash(value),
+		CreatedAt: now,
+		Scopes:    scopes,
+	}
+
+	if ttl > 0 {
+		t.ExpiresAt = now.Add(ttl)
+	}
+
+	return t, value, nil
+}
+
+// Authenticate returns the token matching the given value and records its use.
+// Legacy tokens are hashed and saved on the fly.
+func Authenticate(value string) (*Token, error) {
+	tokens, err := resource.List[Token](Store)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tokens {
+		if t.Value == "" {
+			continue
+		}
+
+		migrate(t)
+
+		if err = save(t); err != nil {
+			return nil, err
+		}
+	}
+
+	hashed := hash(value)
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashed)) != 1 {
+			continue
+		}
+
+		now := time.Now()
+
+		if t.IsExpired(now) {
+			return nil, fmt.Errorf("%w on %s", ErrExpiredToken, t.ExpiresAt.Format(time.RFC822))
+		}
+
+		t.LastUsedAt = now
+
+		err = save(t)
+
+		return t, err
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// migrate hashes the value of a legacy token, which had access to every route but the admin ones.
+func migrate(t *Token) {
+	t.Hash = hash(t.Value)
+	t.Value = ""
+
+	if len(t.Scopes) == 0 {
+		t.Scopes = DefaultScopes
+	}
+}
+
+func save(t *Token) error {
+	return resource.Save[*Token](Store, t, func(t *Token) string {
+		return t.Label
+	})
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func isScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tok, value, err := New("ci", nil, 0)
+	assert.NilError(t, err)
+
+	assert.Equal(t, tok.Label, "ci")
+	assert.Equal(t, tok.Value, "")
+	assert.Equal(t, tok.Hash, hash(value))
+	assert.DeepEqual(t, tok.Scopes, DefaultScopes)
+	assert.Assert(t, tok.ExpiresAt.IsZero())
+
+	tok, _, err = New("ci", []string{ScopeDeploy}, time.Hour)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tok.Scopes, []string{ScopeDeploy})
+	assert.Assert(t, tok.ExpiresAt.After(time.Now()))
+
+	_, _, err = New("ci", []string{"write:everything"}, 0)
+	assert.ErrorIs(t, err, ErrUnknownScope)
+}
+
+func TestAuthenticate(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	tok, value, err := New("ci", []string{ScopeDeploy}, 0)
+	assert.NilError(t, err)
+	assert.NilError(t, save(tok))
+
+	got, err := Authenticate(value)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Label, "ci")
+	assert.Assert(t, !got.LastUsedAt.IsZero())
+
+	// the last use is persisted
+	saved, err := resource.Get[Token](Store, "ci")
+	assert.NilError(t, err)
+	assert.Assert(t, !saved.LastUsedAt.IsZero())
+
+	_, err = Authenticate("tok_unknown")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthenticate2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	tok, value, err := New("ci", nil, time.Hour)
+	assert.NilError(t, err)
+	tok.ExpiresAt = time.Now().Add(-time.Minute)
+	assert.NilError(t, save(tok))
+
+	_, err = Authenticate(value)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestAuthenticate3(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	// legacy tokens are stored in plain text
+	err := save(&Token{Label: "legacy", Value: "tok_legacy"})
+	assert.NilError(t, err)
+	err = save(&Token{Label: "other", Value: "tok_other"})
+	assert.NilError(t, err)
+
+	got, err := Authenticate("tok_legacy")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.Scopes, DefaultScopes)
+
+	tokens, err := resource.List[Token](Store)
+	assert.NilError(t, err)
+
+	for _, saved := range tokens {
+		assert.Equal(t, saved.Value, "")
+		assert.Equal(t, saved.Hash, hash("tok_"+saved.Label))
+	}
+}
+
+func TestToken_HasScope(t *testing.T) {
+	tok := Token{Scopes: []string{ScopeDeploy}}
+	assert.Assert(t, tok.HasScope(ScopeDeploy))
+	assert.Assert(t, !tok.HasScope(ScopeReadConfig))
+	assert.Assert(t, !tok.HasScope(ScopeAdmin))
+
+	admin := Token{Scopes: []string{ScopeAdmin}}
+	assert.Assert(t, admin.HasScope(ScopeReadMetrics))
+	assert.Assert(t, admin.HasScope(ScopeAdmin))
+}
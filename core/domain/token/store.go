@@ -1,17 +1,44 @@
 package token
 
 import (
+	"fmt"
 	"github.com/vite-cloud/vite/core/domain/datadir"
+	"strings"
 	"time"
 )
 
 const Store = datadir.Store("tokens")
 
+// Available scopes.
+const (
+	// ScopeDeploy allows to start deployments.
+	ScopeDeploy = "deploy"
+	// ScopeReadConfig allows to read the redacted config.
+	ScopeReadConfig = "read:config"
+	// ScopeReadMetrics allows to read the metrics of the host and its containers.
+	ScopeReadMetrics = "read:metrics"
+	// ScopeAdmin grants every other scope and access to sensitive data such as the unredacted config.
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists the scopes a token may be granted.
+var Scopes = []string{ScopeDeploy, ScopeReadConfig, ScopeReadMetrics, ScopeAdmin}
+
+// DefaultScopes are granted to tokens created without explicit scopes and to legacy tokens.
+var DefaultScopes = []string{ScopeDeploy, ScopeReadConfig, ScopeReadMetrics}
+
 type Token struct {
-	Label      string
-	Value      string
+	Label string
+	// Value is only set for legacy tokens, created before tokens were hashed.
+	Value string `json:",omitempty"`
+	// Hash is the SHA-256 hash of the token, the token itself is only shown once at creation.
+	Hash       string
 	CreatedAt  time.Time
 	LastUsedAt time.Time
+	// ExpiresAt is the time after which the token is rejected, the token never expires if it is zero.
+	ExpiresAt time.Time
+	// Scopes grant permissions to the token.
+	Scopes []string
 }
 
 func (t Token) Time() time.Time {
@@ -21,3 +48,36 @@ func (t Token) Time() time.Time {
 func (t Token) ID() string {
 	return t.Label
 }
+
+// HasScope returns true if the token was granted the given scope or the admin scope.
+func (t Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExpired returns true if the token expired at the given time.
+func (t Token) IsExpired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
+// Summary returns the scopes, expiry and last use of the token.
+func (t Token) Summary() string {
+	expiry := "never expires"
+	if t.IsExpired(time.Now()) {
+		expiry = "expired " + t.ExpiresAt.Format(time.RFC822)
+	} else if !t.ExpiresAt.IsZero() {
+		expiry = "expires " + t.ExpiresAt.Format(time.RFC822)
+	}
+
+	lastUse := "never used"
+	if !t.LastUsedAt.IsZero() {
+		lastUse = "last used " + t.LastUsedAt.Format(time.RFC822)
+	}
+
+	return fmt.Sprintf("%s | %s | %s", strings.Join(t.Scopes, ", "), expiry, lastUse)
+}
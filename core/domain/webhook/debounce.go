@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// DebounceDelay is how long to wait for other pushes before deploying.
+const DebounceDelay = 5 * time.Second
+
+// Debouncer coalesces pushes received in a short period of time so that only the latest one is deployed.
+// Pushes received while a deployment is running are deployed once it finishes.
+type Debouncer struct {
+	delay  time.Duration
+	deploy func(Push)
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	latest  Push
+	running bool
+	pending bool
+}
+
+// NewDebouncer returns a Debouncer calling deploy with the latest push once no push was received for the given delay.
+func NewDebouncer(delay time.Duration, deploy func(Push)) *Debouncer {
+	return &Debouncer{
+		delay:  delay,
+		deploy: deploy,
+	}
+}
+
+// Push schedules the deployment of a push, replacing any push not deployed yet.
+func (d *Debouncer) Push(push Push) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.latest = push
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	d.timer = time.AfterFunc(d.delay, d.fire)
+}
+
+// fire deploys the latest push unless a deployment is running, in which case it is deployed afterwards.
+func (d *Debouncer) fire() {
+	d.mu.Lock()
+	if d.running {
+		d.pending = true
+		d.mu.Unlock()
+		return
+	}
+
+	d.running = true
+	push := d.latest
+	d.mu.Unlock()
+
+	d.deploy(push)
+
+	d.mu.Lock()
+	d.running = false
+	pending := d.pending
+	d.pending = false
+	d.mu.Unlock()
+
+	if pending {
+		d.fire()
+	}
+}
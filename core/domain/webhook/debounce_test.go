@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"gotest.tools/v3/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebouncer_Push(t *testing.T) {
+	var mu sync.Mutex
+	var deployed []string
+
+	done := make(chan struct{}, 10)
+
+	debouncer := NewDebouncer(20*time.Millisecond, func(push Push) {
+		mu.Lock()
+		deployed = append(deployed, push.Commit)
+		mu.Unlock()
+
+		done <- struct{}{}
+	})
+
+	debouncer.Push(Push{Commit: "a"})
+	debouncer.Push(Push{Commit: "b"})
+	debouncer.Push(Push{Commit: "c"})
+
+	<-done
+
+	debouncer.Push(Push{Commit: "d"})
+
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.DeepEqual(t, deployed, []string{"c", "d"})
+}
+
+func TestDebouncer_Push2(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan string, 10)
+
+	debouncer := NewDebouncer(time.Millisecond, func(push Push) {
+		if push.Commit == "a" {
+			<-release
+		}
+
+		done <- push.Commit
+	})
+
+	debouncer.Push(Push{Commit: "a"})
+	time.Sleep(20 * time.Millisecond)
+
+	// pushed while "a" is being deployed
+	debouncer.Push(Push{Commit: "b"})
+	time.Sleep(20 * time.Millisecond)
+
+	close(release)
+
+	assert.Equal(t, <-done, "a")
+	assert.Equal(t, <-done, "b")
+}
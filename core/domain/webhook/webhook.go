@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// SecretName is the name of the secret used to verify webhook signatures.
+// It is set with `vite secrets set webhook`.
+const SecretName = "webhook"
+
+var (
+	// ErrUnsupportedProvider is returned when receiving a webhook from an unknown provider.
+	ErrUnsupportedProvider = errors.New("unsupported provider (accepts: github, gitlab, bitbucket)")
+	// ErrInvalidSignature is returned when the signature of a webhook does not match its payload.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Push is a push to a branch of the repository.
+type Push struct {
+	// Provider is the provider that sent the webhook.
+	Provider string
+	Branch   string
+	Commit   string
+}
+
+// Receive verifies the signature of a webhook sent by the given provider and returns the push it describes.
+// It returns a nil push for events other than pushes to a branch, such as tags or deleted branches.
+func Receive(provider string, header http.Header, body []byte, secret string) (*Push, error) {
+	switch provider {
+	case "github":
+		if !validHMAC(header.Get("X-Hub-Signature-256"), body, secret) {
+			return nil, ErrInvalidSignature
+		}
+
+		if header.Get("X-GitHub-Event") != "push" {
+			return nil, nil
+		}
+
+		return parseRefPush(provider, body)
+	case "gitlab":
+		// GitLab sends the secret as is instead of signing the payload.
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secret)) != 1 {
+			return nil, ErrInvalidSignature
+		}
+
+		if header.Get("X-Gitlab-Event") != "Push Hook" {
+			return nil, nil
+		}
+
+		return parseRefPush(provider, body)
+	case "bitbucket":
+		if !validHMAC(header.Get("X-Hub-Signature"), body, secret) {
+			return nil, ErrInvalidSignature
+		}
+
+		if header.Get("X-Event-Key") != "repo:push" {
+			return nil, nil
+		}
+
+		return parseBitbucketPush(body)
+	default:
+		return nil, ErrUnsupportedProvider
+	}
+}
+
+// validHMAC checks a signature in the form sha256=<hex encoded HMAC of the body>.
+func validHMAC(signature string, body []byte, secret string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// parseRefPush parses the payload of push events sent by GitHub and GitLab.
+func parseRefPush(provider string, body []byte) (*Push, error) {
+	var payload struct {
+		Ref   string `json:"ref"`
+		After string `json:"after"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	// a deleted branch is pushed with a zeroed commit
+	if !strings.HasPrefix(payload.Ref, "refs/heads/") || strings.Trim(payload.After, "0") == "" {
+		return nil, nil
+	}
+
+	return &Push{
+		Provider: provider,
+		Branch:   strings.TrimPrefix(payload.Ref, "refs/heads/"),
+		Commit:   payload.After,
+	}, nil
+}
+
+// parseBitbucketPush parses the payload of repo:push events sent by Bitbucket.
+func parseBitbucketPush(body []byte) (*Push, error) {
+	var payload struct {
+		Push struct {
+			Changes []struct {
+				New *struct {
+					Type   string `json:"type"`
+					Name   string `json:"name"`
+					Target struct {
+						Hash string `json:"hash"`
+					} `json:"target"`
+				} `json:"new"`
+			} `json:"changes"`
+		} `json:"push"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	// the last change is the most recent one, new is null if the branch was deleted
+	for i := len(payload.Push.Changes) - 1; i >= 0; i-- {
+		change := payload.Push.Changes[i].New
+		if change == nil || change.Type != "branch" {
+			continue
+		}
+
+		return &Push{
+			Provider: "bitbucket",
+			Branch:   change.Name,
+			Commit:   change.Target.Hash,
+		}, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"gotest.tools/v3/assert"
+	"net/http"
+	"testing"
+)
+
+const commit = "4e1aeb171526b75e0e891c924d4d2448f563cb7d"
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func header(pairs ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+
+	return h
+}
+
+func TestReceive(t *testing.T) {
+	refPush := `{"ref":"refs/heads/main","after":"` + commit + `"}`
+	bitbucketPush := `{"push":{"changes":[{"new":{"type":"branch","name":"main","target":{"hash":"` + commit + `"}}}]}}`
+
+	tests := []struct {
+		provider string
+		header   http.Header
+		body     string
+		push     *Push
+	}{
+		{"github", header("X-Hub-Signature-256", sign(refPush, "secret"), "X-GitHub-Event", "push"), refPush, &Push{"github", "main", commit}},
+		{"gitlab", header("X-Gitlab-Token", "secret", "X-Gitlab-Event", "Push Hook"), refPush, &Push{"gitlab", "main", commit}},
+		{"bitbucket", header("X-Hub-Signature", sign(bitbucketPush, "secret"), "X-Event-Key", "repo:push"), bitbucketPush, &Push{"bitbucket", "main", commit}},
+		// events other than pushes are ignored
+		{"github", header("X-Hub-Signature-256", sign("{}", "secret"), "X-GitHub-Event", "ping"), "{}", nil},
+		// tags and deleted branches are ignored
+		{"github", header("X-Hub-Signature-256", sign(`{"ref":"refs/tags/v1","after":"`+commit+`"}`, "secret"), "X-GitHub-Event", "push"), `{"ref":"refs/tags/v1","after":"` + commit + `"}`, nil},
+		{"gitlab", header("X-Gitlab-Token", "secret", "X-Gitlab-Event", "Push Hook"), `{"ref":"refs/heads/main","after":"0000000000000000000000000000000000000000"}`, nil},
+		{"bitbucket", header("X-Hub-Signature", sign(`{"push":{"changes":[{"new":null}]}}`, "secret"), "X-Event-Key", "repo:push"), `{"push":{"changes":[{"new":null}]}}`, nil},
+	}
+
+	for _, test := range tests {
+		push, err := Receive(test.provider, test.header, []byte(test.body), "secret")
+		assert.NilError(t, err, test.provider)
+		assert.DeepEqual(t, push, test.push)
+	}
+}
+
+func TestReceive2(t *testing.T) {
+	body := `{"ref":"refs/heads/main","after":"` + commit + `"}`
+
+	tests := []struct {
+		provider string
+		header   http.Header
+		err      error
+	}{
+		{"github", header("X-GitHub-Event", "push"), ErrInvalidSignature},
+		{"github", header("X-Hub-Signature-256", sign(body, "other"), "X-GitHub-Event", "push"), ErrInvalidSignature},
+		{"github", header("X-Hub-Signature-256", "sha256=not-hex", "X-GitHub-Event", "push"), ErrInvalidSignature},
+		{"gitlab", header("X-Gitlab-Token", "other", "X-Gitlab-Event", "Push Hook"), ErrInvalidSignature},
+		{"bitbucket", header("X-Hub-Signature", sign(body, "other"), "X-Event-Key", "repo:push"), ErrInvalidSignature},
+		{"gitea", header(), ErrUnsupportedProvider},
+	}
+
+	for _, test := range tests {
+		_, err := Receive(test.provider, test.header, []byte(body), "secret")
+		assert.ErrorIs(t, err, test.err, test.provider)
+	}
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+)
+
+// IsInteractive returns true if stdin is a terminal, in which case the user may be prompted.
+func (c *CLI) IsInteractive() bool {
+	return term.IsTerminal(int(c.in.Fd()))
+}
+
+// AskOne prompts the user for a value if it is empty and stdin is a terminal.
+// If stdin is not a terminal, it fails with an error mentioning the flag to set instead.
+// The value is validated the same way whether it was prompted for or given as a flag.
+func (c *CLI) AskOne(value *string, flag string, prompt survey.Prompt, validate survey.Validator) error {
+	if *value != "" {
+		return validate(*value)
+	}
+
+	if !c.IsInteractive() {
+		return fmt.Errorf("missing value for --%s (prompts are disabled as stdin is not a terminal)", flag)
+	}
+
+	return survey.AskOne(prompt, value, survey.WithStdio(c.in, c.out, c.err), survey.WithValidator(validate))
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func NewRootCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "validate vite.yaml and print its schema",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(NewValidateCommand(cli))
+	cmd.AddCommand(NewSchemaCommand(cli))
+
+	return cmd
+}
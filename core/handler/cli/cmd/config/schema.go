@@ -0,0 +1,28 @@
+package config
+
+import (
+	"encoding/json"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runSchemaCommand(cli *cli.CLI) error {
+	encoder := json.NewEncoder(cli.Out())
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(config.Schema())
+}
+
+func NewSchemaCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "print the JSON Schema of vite.yaml, for editors to validate and complete it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSchemaCommand(cli)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+type validateOptions struct {
+	environment string
+}
+
+func runValidateCommand(cli *cli.CLI, file string, opts validateOptions) error {
+	var loc *locator.Locator
+	var err error
+
+	if file != "" {
+		loc, err = locator.NewLocal(file, opts.environment)
+	} else if opts.environment != "" {
+		return fmt.Errorf("--environment can only be used with a file, run `vite setup --environment %s` instead", opts.environment)
+	} else {
+		loc, err = locator.LoadFromStore()
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = config.Parse(loc); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.Out(), "The config is valid.")
+
+	return nil
+}
+
+func NewValidateCommand(cli *cli.CLI) *cobra.Command {
+	opts := validateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "validate a local vite.yaml, or the config of the current commit if none is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var file string
+			if len(args) == 1 {
+				file = args[0]
+			}
+
+			return runValidateCommand(cli, file, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.environment, "environment", "", "environment of the file, its overlay is merged over vite.yaml")
+
+	return cmd
+}
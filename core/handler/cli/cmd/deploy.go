@@ -1,44 +1,86 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/vite-cloud/vite/core/domain/deployment"
 	"github.com/vite-cloud/vite/core/domain/locator"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"os/user"
 )
 
-func runDeployCommand(cli *cli.CLI) error {
-	loc, err := locator.LoadFromStore()
+type deployOptions struct {
+	json        bool
+	config      string
+	environment string
+}
+
+func runDeployCommand(cli *cli.CLI, opts deployOptions) error {
+	var loc *locator.Locator
+	var err error
+
+	// a local config is deployed as is, without changing the saved locator
+	if opts.config != "" {
+		loc, err = locator.NewLocal(opts.config, opts.environment)
+	} else if opts.environment != "" {
+		return fmt.Errorf("--environment can only be used with --config, run `vite setup --environment %s` instead", opts.environment)
+	} else {
+		loc, err = locator.LoadFromStore()
+	}
 	if err != nil {
 		return err
 	}
 
 	events := make(chan deployment.Event)
 
-	go deployment.Deploy(events, loc)
+	go deployment.Deploy(events, loc, deployment.WithTrigger(trigger()))
 
+	// A failed deployment is rolled back before the channel is closed,
+	// so events are read until the end even if a service failed.
 	for event := range events {
-		if event.ID == deployment.FinishEvent {
-			break
-		}
+		if opts.json {
+			if err = json.NewEncoder(cli.Out()).Encode(event); err != nil {
+				return err
+			}
 
-		fmt.Fprintf(cli.Out(), "%s(%s): %v\n", event.Label(), event.ID, event.Data)
+			continue
+		}
 
-		if event.IsError() {
-			break
+		if event.ID == deployment.FinishEvent {
+			continue
 		}
+
+		fmt.Fprintln(cli.Out(), event)
 	}
 
 	return nil
 }
 
+// trigger identifies the user running the deployment from the CLI.
+func trigger() string {
+	u, err := user.Current()
+	if err != nil {
+		return "cli"
+	}
+
+	return "cli:" + u.Username
+}
+
 func NewDeployCommand(cli *cli.CLI) *cobra.Command {
-	return &cobra.Command{
+	opts := deployOptions{}
+
+	cmd := &cobra.Command{
 		Use:   "deploy",
 		Short: "deploy services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeployCommand(cli)
+			return runDeployCommand(cli, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.json, "json", false, "print events as JSON, one per line")
+	cmd.Flags().StringVar(&opts.environment, "environment", "", "environment of the local config, its overlay is merged over vite.yaml (requires --config)")
+	cmd.Flags().StringVar(&opts.config, "config", "", "deploy a local vite.yaml, or a directory containing one, instead of the configured repository")
+
+	return cmd
 }
@@ -1,23 +1,76 @@
 package deployments
 
 import (
+	"context"
+	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/resource"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
 )
 
-func runCleanupCommand(cli *cli.CLI) error {
+type cleanupOptions struct {
+	force bool
+}
+
+func runCleanupCommand(cli *cli.CLI, ID string, opts cleanupOptions) error {
+	dep, err := resource.Get[deployment.Deployment](deployment.Store, ID)
+	if err != nil {
+		return err
+	}
+
+	// The deployment in use can't change while deployments are locked.
+	lock, err := deployment.LockDeployments()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if !opts.force {
+		inUse, err := dep.InUse()
+		if err != nil {
+			return err
+		}
+
+		if latest, err := deployment.Latest(); inUse || (err == nil && latest.ID() == dep.ID()) {
+			return fmt.Errorf("%w, use --force to clean it up anyway", deployment.ErrDeploymentInUse)
+		}
+	}
+
+	events := make(chan deployment.Event)
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- dep.Cleanup(context.Background(), events)
+		close(events)
+	}()
+
+	for event := range events {
+		fmt.Fprintln(cli.Out(), event)
+	}
+
+	if err = <-errs; err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Out(), "\nDeployment %s has been cleaned up.\n", dep.ID())
+
 	return nil
 }
 
 func newCleanupCommand(cli *cli.CLI) *cobra.Command {
+	opts := cleanupOptions{}
+
 	cmd := &cobra.Command{
 		Use:   "cleanup [deployment]",
 		Short: "cleanup a given deployment",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCleanupCommand(cli)
+			return runCleanupCommand(cli, args[0], opts)
 		},
 	}
 
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "cleanup the deployment even if it is in use")
+
 	return cmd
 }
@@ -0,0 +1,114 @@
+package deployments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/log"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"os"
+	"time"
+)
+
+type eventsOptions struct {
+	follow bool
+	json   bool
+}
+
+func runEventsCommand(cli *cli.CLI, ID string, opts eventsOptions) error {
+	running, err := isRunning(ID)
+	if err != nil {
+		return err
+	}
+
+	if !opts.follow || !running {
+		events, err := deployment.ReadEvents(ID)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err = printEvent(cli, event, opts); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	path, err := deployment.EventLogPath(ID)
+	if err != nil {
+		return err
+	}
+
+	stream, err := log.Tail(path, log.TailOptions{
+		Stream:   true,
+		Backfill: -1,
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w %s", deployment.ErrNoEventLog, ID)
+	} else if err != nil {
+		return err
+	}
+
+	for line := range stream {
+		event, err := deployment.ParseEvent(line)
+		if err != nil {
+			return err
+		}
+
+		if err = printEvent(cli, event, opts); err != nil {
+			return err
+		}
+
+		if event.IsFinish() {
+			break
+		}
+	}
+
+	return nil
+}
+
+func printEvent(cli *cli.CLI, event deployment.Event, opts eventsOptions) error {
+	if opts.json {
+		return json.NewEncoder(cli.Out()).Encode(event)
+	}
+
+	_, err := fmt.Fprintf(cli.Out(), "[%s] %s\n", event.Time.Format(time.Stamp), event)
+
+	return err
+}
+
+// isRunning returns true if the deployment is still in progress.
+// The manifest is only saved once the first replica of the deployment is ready.
+func isRunning(ID string) (bool, error) {
+	dep, err := resource.Get[deployment.Deployment](deployment.Store, ID)
+	if errors.Is(err, os.ErrNotExist) {
+		return deployment.IsDeploying()
+	} else if err != nil {
+		return false, err
+	}
+
+	return dep.IsRunning()
+}
+
+func newEventsCommand(cli *cli.CLI) *cobra.Command {
+	opts := eventsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "events [deployment]",
+		Short: "show the events of a given deployment",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEventsCommand(cli, args[0], opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.json, "json", false, "print events as JSON, one per line")
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "follow the events until the deployment is over")
+
+	return cmd
+}
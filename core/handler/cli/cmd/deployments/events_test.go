@@ -0,0 +1,76 @@
+package deployments
+
+import (
+	"encoding/json"
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"gotest.tools/v3/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunEventsCommand(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	lock, err := deployment.LockDeployments()
+	assert.NilError(t, err)
+	defer lock.Unlock()
+
+	// the manifest of a running deployment is saved once its first replica is ready
+	err = resource.Save[*deployment.Deployment](deployment.Store, &deployment.Deployment{Status: deployment.StatusRunning}, func(*deployment.Deployment) string {
+		return "1"
+	})
+	assert.NilError(t, err)
+
+	path, err := deployment.EventLogPath("1")
+	assert.NilError(t, err)
+
+	log, err := os.Create(path)
+	assert.NilError(t, err)
+	defer log.Close()
+
+	write := func(event deployment.Event) {
+		event.Time = time.Now()
+
+		line, err := json.Marshal(event)
+		assert.NilError(t, err)
+
+		_, err = log.Write(append(line, '\n'))
+		assert.NilError(t, err)
+	}
+
+	write(deployment.Event{ID: deployment.ReplicaReady})
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	assert.NilError(t, err)
+	defer out.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- runEventsCommand(cli.New(out, os.Stdin, os.Stderr), "1", eventsOptions{follow: true})
+	}()
+
+	select {
+	case err = <-done:
+		t.Fatalf("stopped following a running deployment: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	write(deployment.Event{ID: deployment.FinishEvent})
+
+	select {
+	case err = <-done:
+		assert.NilError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("kept following after the deployment finished")
+	}
+
+	printed, err := os.ReadFile(out.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Count(string(printed), "\n"), 2)
+}
@@ -12,14 +12,18 @@ var manager = resource.Manager[*deployment.Deployment]{
 }
 
 func newListCommand(cli *cli.CLI) *cobra.Command {
+	var asJSON bool
+
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "list deployments",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return manager.ListCommand(cli)
+			return manager.ListCommand(cli, asJSON)
 		},
 	}
 
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print deployments as JSON")
+
 	return cmd
 }
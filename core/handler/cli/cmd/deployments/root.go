@@ -15,6 +15,7 @@ func NewDeploymentsCommand(c *cli.CLI) *cobra.Command {
 	cmd.AddCommand(
 		newListCommand(c),
 		newCleanupCommand(c),
+		newEventsCommand(c),
 		newRollbackCommand(c),
 		newShowCommand(c),
 	)
@@ -6,14 +6,18 @@ import (
 )
 
 func newShowCommand(cli *cli.CLI) *cobra.Command {
+	var asJSON bool
+
 	cmd := &cobra.Command{
 		Use:   "show [deployment]",
 		Short: "show details about a given deployment",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return manager.ShowCommand(cli, args[0])
+			return manager.ShowCommand(cli, args[0], asJSON)
 		},
 	}
 
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the deployment as JSON")
+
 	return cmd
 }
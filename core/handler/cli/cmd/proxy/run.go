@@ -1,6 +1,8 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/vite-cloud/go-zoup"
 	"github.com/vite-cloud/vite/core/domain/config"
@@ -9,13 +11,20 @@ import (
 	"github.com/vite-cloud/vite/core/domain/resource"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
 	"strconv"
+	"time"
 )
 
+// watchInterval is the interval at which the proxy looks for a new deployment.
+const watchInterval = 2 * time.Second
+
 type runOpts struct {
 	deployment *deployment.Deployment
 	HTTP       string
 	HTTPS      string
 	Unsecure   bool
+	// watch is true when the proxy follows the latest successful deployment
+	// rather than being pinned to a given one.
+	watch bool
 }
 
 func runRunCommand(cli *cli.CLI, opts *runOpts) error {
@@ -24,7 +33,14 @@ func runRunCommand(cli *cli.CLI, opts *runOpts) error {
 		return err
 	}
 
-	proxy.Logger.Log(zoup.DebugLevel, "starting", zoup.Fields{"http_port": opts.HTTP, "https_port": opts.HTTPS, "secure": !opts.Unsecure})
+	proxy.Logger.Log(zoup.DebugLevel, "starting", zoup.Fields{"http_port": opts.HTTP, "https_port": opts.HTTPS, "secure": !opts.Unsecure, "watch": opts.watch})
+
+	if opts.watch {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go proxy.Router.Watch(ctx, watchInterval)
+	}
 
 	proxy.Run(opts.HTTP, opts.HTTPS, opts.Unsecure)
 
@@ -37,16 +53,32 @@ func newRunCommand(cli *cli.CLI) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run [id]",
 		Short: "run the proxy",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.RangeArgs(0, 1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id, err := strconv.Atoi(args[0])
-			if err != nil {
-				return err
-			}
-
-			dep, err := resource.Get[deployment.Deployment](deployment.Store, id)
-			if err != nil {
-				return err
+			var dep *deployment.Deployment
+
+			if len(args) == 0 {
+				latest, err := deployment.Latest()
+				if err != nil {
+					return err
+				}
+
+				dep = latest
+				opts.watch = true
+			} else {
+				id, err := strconv.Atoi(args[0])
+				if err != nil {
+					return err
+				}
+
+				dep, err = resource.Get[deployment.Deployment](deployment.Store, id)
+				if err != nil {
+					return err
+				}
+
+				if dep.Status != deployment.StatusSucceeded {
+					return fmt.Errorf("%w: deployment %s has status %q", deployment.ErrDeploymentNotSucceeded, dep.ID(), dep.Status)
+				}
 			}
 
 			conf, err := config.Get(dep.Locator)
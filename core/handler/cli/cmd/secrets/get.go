@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runGetCommand(cli *cli.CLI, name string) error {
+	value, err := secret.Get(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.Out(), value)
+
+	return nil
+}
+
+func NewGetCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get [name]",
+		Short: "print the decrypted value of a secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetCommand(cli, args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,25 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/resource"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+var manager = resource.Manager[secret.Secret]{
+	Store: secret.Store,
+}
+
+func NewListCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list secrets, without their values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return manager.ListCommand(cli, false)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runRemoveCommand(cli *cli.CLI, name string) error {
+	err := secret.Remove(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Out(), "The secret %s has been removed.\n", name)
+
+	return nil
+}
+
+func NewRemoveCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm [name]",
+		Short: "remove a secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemoveCommand(cli, args[0])
+		},
+	}
+
+	return cmd
+}
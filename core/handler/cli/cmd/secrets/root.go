@@ -0,0 +1,21 @@
+package secrets
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func NewRootCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "manage secrets referenced in vite.yaml as ${secret:NAME}",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(NewSetCommand(cli))
+	cmd.AddCommand(NewGetCommand(cli))
+	cmd.AddCommand(NewListCommand(cli))
+	cmd.AddCommand(NewRemoveCommand(cli))
+
+	return cmd
+}
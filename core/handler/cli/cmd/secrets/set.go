@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"fmt"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/secret"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runSetCommand(cli *cli.CLI, name string, args []string) error {
+	var value string
+
+	// Prompting keeps the value out of the shell history.
+	if len(args) > 0 {
+		value = args[0]
+	} else {
+		prompt := survey.Password{
+			Message: fmt.Sprintf("Enter the value of %s:", name),
+		}
+
+		err := survey.AskOne(&prompt, &value, survey.WithStdio(cli.In(), cli.Out(), cli.Err()), survey.WithValidator(survey.Required))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := secret.Set(name, value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Out(), "The secret %s has been set.\n", name)
+
+	return nil
+}
+
+func NewSetCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set [name] [value]",
+		Short: "set a secret, the value is prompted for if omitted",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetCommand(cli, args[0], args[1:])
+		},
+	}
+
+	return cmd
+}
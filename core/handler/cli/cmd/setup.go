@@ -3,82 +3,150 @@ package cmd
 import (
 	"fmt"
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
 	"github.com/spf13/cobra"
 	"github.com/vite-cloud/vite/core/domain/locator"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 )
 
-// runSetupCommand handles the `setup` command.
-func runSetupCommand(cli *cli.CLI) error {
-	var qs = []*survey.Question{
-		{
-			Name: "provider",
-			Prompt: &survey.Select{
-				Message: "Select your provider:",
-				Options: []string{"github", "gitlab", "bitbucket"},
-			},
-			Validate: survey.Required,
-		},
-		{
-			Name: "protocol",
-			Prompt: &survey.Select{
-				Message: "Select your protocol:",
-				Options: []string{"ssh", "https", "auto"},
-				Default: "ssh",
-			},
-			Validate: survey.Required,
-		},
-		{
-			Name: "repository",
-			Prompt: &survey.Input{
-				Message: "Enter your repository:",
-			},
-			Validate: func(ans interface{}) error {
-				re := regexp.MustCompile("[a-zA-Z0-9-_]+/[a-zA-Z0-9-_]+")
-				if !re.MatchString(ans.(string)) {
-					return fmt.Errorf("repository must be in format: username/repository")
-				}
+var (
+	providers = []string{"github", "gitlab", "bitbucket"}
+	protocols = []string{locator.ProtocolSSH, locator.ProtocolHTTPS, locator.ProtocolAuto}
+)
+
+const (
+	// otherProvider is selected to clone the repository from a full remote URL.
+	otherProvider = "other"
+	// localProvider is selected to read the config from the disk.
+	localProvider = "local"
+)
+
+type setupOptions struct {
+	provider    string
+	protocol    string
+	host        string
+	remote      string
+	local       string
+	sshCommand  string
+	repository  string
+	branch      string
+	path        string
+	environment string
+	// hasPath is true if --path was given, as an empty path is valid.
+	hasPath bool
+	// hasHost is true if --host was given, as the host is optional.
+	hasHost bool
+	// hasEnvironment is true if --environment was given, as the environment is optional.
+	hasEnvironment bool
+}
+
+func validateRepository(ans interface{}) error {
+	re := regexp.MustCompile("[a-zA-Z0-9-_]+/[a-zA-Z0-9-_]+")
+	if !re.MatchString(ans.(string)) {
+		return fmt.Errorf("repository must be in format: username/repository")
+	}
+	return nil
+}
+
+func validateRemote(ans interface{}) error {
+	re := regexp.MustCompile(`^((ssh|git|https?|file)://\S+|[\w.-]+@[\w.-]+:\S+|/\S*)$`)
+	if !re.MatchString(ans.(string)) {
+		return fmt.Errorf("remote must be a URL (ssh://, https://, git://, file://), in the form user@host:repository or an absolute path")
+	}
+	return nil
+}
+
+func validateLocal(ans interface{}) error {
+	if _, err := os.Stat(ans.(string)); err != nil {
+		return fmt.Errorf("local config must be an existing file or directory: %w", err)
+	}
+	return nil
+}
+
+func validateEnvironment(ans interface{}) error {
+	re := regexp.MustCompile(`^[a-zA-Z0-9_-]*$`)
+	if !re.MatchString(ans.(string)) {
+		return fmt.Errorf("environment may only contain letters, digits, - and _")
+	}
+	return nil
+}
+
+// hostValidator returns a validator for the host of a repository reached using the given protocol.
+// A port only applies to one protocol, so it can't be used with auto, which tries both ssh and https.
+func hostValidator(protocol string) survey.Validator {
+	return func(ans interface{}) error {
+		re := regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?$`)
+		if !re.MatchString(ans.(string)) {
+			return fmt.Errorf("host must be a domain name, optionally followed by a port (e.g. gitlab.example.com)")
+		}
+		if protocol == locator.ProtocolAuto && strings.Contains(ans.(string), ":") {
+			return fmt.Errorf("host may only have a port with the ssh or https protocol, not auto")
+		}
+		return nil
+	}
+}
+
+// oneOf returns a validator accepting only the given options.
+func oneOf(options ...string) survey.Validator {
+	return func(ans interface{}) error {
+		value := fmt.Sprint(ans)
+		if option, ok := ans.(core.OptionAnswer); ok {
+			value = option.Value
+		}
+
+		for _, option := range options {
+			if option == value {
 				return nil
-			},
-		},
-		{
-			Name: "branch",
-			Prompt: &survey.Input{
-				Message: "Enter your branch:",
-				Default: "main",
-			},
-			Validate: survey.Required,
-		},
-		{
-			Name: "path",
-			Prompt: &survey.Input{
-				Message: "Enter a sub-path (optional):",
-				Default: "",
-			},
-		},
+			}
+		}
+
+		return fmt.Errorf("invalid value %s, expected one of %s", value, strings.Join(options, ", "))
+	}
+}
+
+// optional accepts any value.
+func optional(interface{}) error {
+	return nil
+}
+
+// runSetupCommand handles the `setup` command.
+// Values missing from the flags are prompted for.
+func runSetupCommand(cli *cli.CLI, opts setupOptions) error {
+	if opts.local == "" && opts.remote == "" {
+		if err := askRepository(cli, &opts); err != nil {
+			return err
+		}
 	}
 
-	answers := struct {
-		Provider   string
-		Protocol   string
-		Repository string
-		Branch     string
-		Path       string
-	}{}
+	var l *locator.Locator
+	var err error
 
-	err := survey.Ask(qs, &answers, survey.WithStdio(cli.In(), cli.Out(), cli.Err()))
+	if opts.local != "" {
+		l, err = setupLocal(opts)
+	} else {
+		l, err = setupRepository(cli, opts)
+	}
 	if err != nil {
 		return err
 	}
 
-	l := locator.Locator{
-		Provider:   locator.Provider(answers.Provider),
-		Protocol:   answers.Protocol,
-		Repository: answers.Repository,
-		Branch:     answers.Branch,
-		Path:       answers.Path,
+	if opts.hasEnvironment {
+		err = validateEnvironment(opts.environment)
+	} else if cli.IsInteractive() {
+		err = cli.AskOne(&opts.environment, "environment", &survey.Input{
+			Message: "Enter an environment (optional):",
+			Default: "",
+		}, validateEnvironment)
 	}
+	if err != nil {
+		return err
+	}
+
+	l.Environment = opts.environment
 
 	err = l.Save()
 	if err != nil {
@@ -90,15 +158,146 @@ func runSetupCommand(cli *cli.CLI) error {
 	return nil
 }
 
+// setupLocal returns a locator reading the config from the disk.
+func setupLocal(opts setupOptions) (*locator.Locator, error) {
+	if err := validateLocal(opts.local); err != nil {
+		return nil, err
+	}
+
+	local, err := filepath.Abs(opts.local)
+	if err != nil {
+		return nil, err
+	}
+
+	return &locator.Locator{Local: local}, nil
+}
+
+// setupRepository returns a locator reading the config from a repository, the branch and path are prompted for.
+func setupRepository(cli *cli.CLI, opts setupOptions) (*locator.Locator, error) {
+	if opts.remote != "" {
+		if err := validateRemote(opts.remote); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.provider == otherProvider {
+		opts.provider = ""
+	}
+
+	err := cli.AskOne(&opts.branch, "branch", &survey.Input{
+		Message: "Enter your branch:",
+		Default: "main",
+	}, survey.Required)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.hasPath && cli.IsInteractive() {
+		err = cli.AskOne(&opts.path, "path", &survey.Input{
+			Message: "Enter a sub-path (optional):",
+			Default: "",
+		}, optional)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &locator.Locator{
+		Provider:   locator.Provider(opts.provider),
+		Protocol:   opts.protocol,
+		Host:       opts.host,
+		Remote:     opts.remote,
+		SSHCommand: opts.sshCommand,
+		Repository: opts.repository,
+		Branch:     opts.branch,
+		Path:       opts.path,
+	}, nil
+}
+
+// askRepository asks for the provider and the repository, for a full remote URL if the provider is other
+// or for a file or directory if the provider is local.
+func askRepository(cli *cli.CLI, opts *setupOptions) error {
+	options := append(providers, otherProvider, localProvider)
+
+	err := cli.AskOne(&opts.provider, "provider", &survey.Select{
+		Message: "Select your provider:",
+		Options: options,
+	}, oneOf(options...))
+	if err != nil {
+		return err
+	}
+
+	if opts.provider == otherProvider {
+		return cli.AskOne(&opts.remote, "remote", &survey.Input{
+			Message: "Enter the URL of your repository:",
+		}, validateRemote)
+	}
+
+	if opts.provider == localProvider {
+		return cli.AskOne(&opts.local, "local", &survey.Input{
+			Message: "Enter the path of your vite.yaml or of its directory:",
+		}, validateLocal)
+	}
+
+	err = cli.AskOne(&opts.protocol, "protocol", &survey.Select{
+		Message: "Select your protocol:",
+		Options: protocols,
+		Default: locator.ProtocolSSH,
+	}, oneOf(protocols...))
+	if err != nil {
+		return err
+	}
+
+	provider := locator.Provider(opts.provider)
+
+	if opts.hasHost {
+		err = hostValidator(opts.protocol)(opts.host)
+	} else if cli.IsInteractive() {
+		err = cli.AskOne(&opts.host, "host", &survey.Input{
+			Message: "Enter your host:",
+			Default: provider.Host(),
+		}, hostValidator(opts.protocol))
+	}
+	if err != nil {
+		return err
+	}
+
+	// the host is only saved if it differs from the public instance of the provider
+	if opts.host == provider.Host() {
+		opts.host = ""
+	}
+
+	return cli.AskOne(&opts.repository, "repository", &survey.Input{
+		Message: "Enter your repository:",
+	}, validateRepository)
+}
+
 // NewSetupCommand creates a new `setup` command.
 func NewSetupCommand(cli *cli.CLI) *cobra.Command {
+	opts := setupOptions{}
+
 	cmd := &cobra.Command{
 		Use:   "setup",
 		Short: "setup vite",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSetupCommand(cli)
+			opts.hasPath = cmd.Flags().Changed("path")
+			opts.hasHost = cmd.Flags().Changed("host")
+			opts.hasEnvironment = cmd.Flags().Changed("environment")
+
+			return runSetupCommand(cli, opts)
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.provider, "provider", "", "provider hosting the repository (github, gitlab, bitbucket, other, local)")
+	cmd.Flags().StringVar(&opts.protocol, "protocol", "", "protocol used to clone the repository (ssh, https, auto)")
+	cmd.Flags().StringVar(&opts.host, "host", "", "host of a self-hosted provider (e.g. gitlab.example.com)")
+	cmd.Flags().StringVar(&opts.remote, "remote", "", "full URL of the repository, replaces --provider, --protocol, --host and --repository")
+	cmd.Flags().StringVar(&opts.local, "local", "", "read vite.yaml, or a directory containing it, from the disk instead of a repository")
+	cmd.Flags().StringVar(&opts.sshCommand, "ssh-command", "", "command used by git to connect over ssh, e.g. to use a deploy key")
+	cmd.Flags().StringVar(&opts.repository, "repository", "", "repository containing vite.yaml (username/repository)")
+	cmd.Flags().StringVar(&opts.branch, "branch", "", "branch to deploy from")
+	cmd.Flags().StringVar(&opts.path, "path", "", "sub-path of vite.yaml in the repository")
+	cmd.Flags().StringVar(&opts.environment, "environment", "", "environment whose overlay (vite.<environment>.yaml) is merged over vite.yaml")
+
 	return cmd
 }
@@ -1,5 +1,14 @@
 package cmd
 
+import (
+	"github.com/vite-cloud/vite/core/domain/datadir"
+	"github.com/vite-cloud/vite/core/domain/locator"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"gotest.tools/v3/assert"
+	"os"
+	"testing"
+)
+
 //func TestNewSetupCommand(t *testing.T) {
 //	datadir.UseTestHome(t)
 //
@@ -32,3 +41,108 @@ package cmd
 //
 //	assert.Equal(t, string(contents), string(marshaled))
 //}
+
+// newNonInteractiveCLI returns a CLI whose stdin is not a terminal.
+func newNonInteractiveCLI(t *testing.T) (*cli.CLI, *os.File) {
+	in, err := os.Open(os.DevNull)
+	assert.NilError(t, err)
+	t.Cleanup(func() { in.Close() })
+
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	assert.NilError(t, err)
+	t.Cleanup(func() { out.Close() })
+
+	return cli.New(out, in, out), out
+}
+
+func TestNewSetupCommand2(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	c, _ := newNonInteractiveCLI(t)
+
+	cmd := NewSetupCommand(c)
+	cmd.SetArgs([]string{"--provider", "gitlab", "--protocol", "https", "--repository", "foo/bar", "--branch", "prod"})
+
+	err := cmd.Execute()
+	assert.NilError(t, err)
+
+	l, err := locator.LoadFromStore()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *l, locator.Locator{
+		Provider:   "gitlab",
+		Protocol:   "https",
+		Repository: "foo/bar",
+		Branch:     "prod",
+	})
+}
+
+func TestNewSetupCommand3(t *testing.T) {
+	datadir.UseTestHome(t)
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--provider", "github", "--protocol", "ssh", "--repository", "foo/bar"}, "missing value for --branch"},
+		{[]string{"--provider", "gitea"}, "invalid value gitea, expected one of github, gitlab, bitbucket, other, local"},
+		{[]string{"--provider", "github", "--protocol", "ssh", "--repository", "foo"}, "repository must be in format"},
+		{[]string{"--provider", "gitlab", "--protocol", "ssh", "--host", "git.example.com/foo"}, "host must be a domain name"},
+		{[]string{"--provider", "gitlab", "--protocol", "auto", "--host", "git.example.com:2222"}, "host may only have a port with the ssh or https protocol"},
+		{[]string{"--remote", "example.com/foo/bar"}, "remote must be a URL"},
+		{[]string{"--provider", "other"}, "missing value for --remote"},
+		{[]string{"--local", "/does-not-exist"}, "local config must be an existing file or directory"},
+		{[]string{"--local", os.TempDir(), "--environment", "../prod"}, "environment may only contain"},
+	}
+
+	for _, test := range tests {
+		c, _ := newNonInteractiveCLI(t)
+
+		cmd := NewSetupCommand(c)
+		cmd.SetArgs(test.args)
+		cmd.SilenceUsage = true
+
+		err := cmd.Execute()
+		assert.ErrorContains(t, err, test.err)
+	}
+}
+
+func TestNewSetupCommand4(t *testing.T) {
+	tests := []struct {
+		args    []string
+		locator locator.Locator
+	}{
+		{
+			[]string{"--provider", "gitlab", "--protocol", "auto", "--host", "git.example.com", "--repository", "foo/bar", "--branch", "main"},
+			locator.Locator{Provider: "gitlab", Protocol: "auto", Host: "git.example.com", Repository: "foo/bar", Branch: "main"},
+		},
+		{
+			// the public host of the provider is not saved
+			[]string{"--provider", "gitlab", "--protocol", "ssh", "--host", "gitlab.com", "--repository", "foo/bar", "--branch", "main"},
+			locator.Locator{Provider: "gitlab", Protocol: "ssh", Repository: "foo/bar", Branch: "main"},
+		},
+		{
+			[]string{"--remote", "file:///srv/git/config.git", "--ssh-command", "ssh -i /root/.ssh/deploy_key", "--branch", "main"},
+			locator.Locator{Remote: "file:///srv/git/config.git", SSHCommand: "ssh -i /root/.ssh/deploy_key", Branch: "main"},
+		},
+		{
+			[]string{"--local", os.TempDir(), "--environment", "staging"},
+			locator.Locator{Local: os.TempDir(), Environment: "staging"},
+		},
+	}
+
+	for _, test := range tests {
+		datadir.UseTestHome(t)
+
+		c, _ := newNonInteractiveCLI(t)
+
+		cmd := NewSetupCommand(c)
+		cmd.SetArgs(test.args)
+
+		err := cmd.Execute()
+		assert.NilError(t, err)
+
+		l, err := locator.LoadFromStore()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, *l, test.locator)
+	}
+}
@@ -0,0 +1,41 @@
+package subnets
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runListCommand(cli *cli.CLI) error {
+	manager, err := runtime.NewSubnetManager()
+	if err != nil {
+		return err
+	}
+
+	subnets, err := manager.List()
+	if err != nil {
+		return err
+	}
+
+	for _, subnet := range subnets {
+		fmt.Fprintf(cli.Out(), "- %s\n", subnet)
+	}
+
+	fmt.Fprintf(cli.Out(), "\n%d allocated.\n", len(subnets))
+
+	return nil
+}
+
+func NewListCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list allocated subnets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runListCommand(cli)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,55 @@
+package subnets
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runReconcileCommand(cli *cli.CLI) error {
+	// A running deployment may have allocated subnets for networks it did not create yet.
+	lock, err := deployment.LockDeployments()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	manager, err := runtime.NewSubnetManager()
+	if err != nil {
+		return err
+	}
+
+	docker, err := runtime.NewClient()
+	if err != nil {
+		return err
+	}
+
+	released, err := manager.Reconcile(context.Background(), docker)
+	if err != nil {
+		return err
+	}
+
+	for _, subnet := range released {
+		fmt.Fprintf(cli.Out(), "- released %s\n", subnet)
+	}
+
+	fmt.Fprintf(cli.Out(), "\n%d released.\n", len(released))
+
+	return nil
+}
+
+func NewReconcileCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "release subnets whose network does not exist anymore",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReconcileCommand(cli)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,37 @@
+package subnets
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runReleaseCommand(cli *cli.CLI, subnet string) error {
+	manager, err := runtime.NewSubnetManager()
+	if err != nil {
+		return err
+	}
+
+	err = manager.Release(subnet)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Out(), "The subnet %s has been released.\n", subnet)
+
+	return nil
+}
+
+func NewReleaseCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release [subnet]",
+		Short: "release a subnet",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReleaseCommand(cli, args[0])
+		},
+	}
+
+	return cmd
+}
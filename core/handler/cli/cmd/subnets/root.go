@@ -0,0 +1,20 @@
+package subnets
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func NewRootCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "subnets",
+		Short: "manage subnets allocated to service networks",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(NewListCommand(cli))
+	cmd.AddCommand(NewReleaseCommand(cli))
+	cmd.AddCommand(NewReconcileCommand(cli))
+
+	return cmd
+}
@@ -7,45 +7,58 @@ import (
 	"github.com/vite-cloud/vite/core/domain/resource"
 	"github.com/vite-cloud/vite/core/domain/token"
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
+	"strings"
 	"time"
 )
 
-func runCreateCommand(cli *cli.CLI) error {
-	tok := token.Token{
-		CreatedAt: time.Now(),
-		Value:     resource.NewIdWithPrefix("tok"),
-	}
+type createOptions struct {
+	label     string
+	scopes    []string
+	expiresIn time.Duration
+}
 
-	label := survey.Input{
+func runCreateCommand(cli *cli.CLI, opts createOptions) error {
+	err := cli.AskOne(&opts.label, "label", &survey.Input{
 		Message: "Enter a label:",
 		Default: "default",
+	}, survey.Required)
+	if err != nil {
+		return err
 	}
-	err := survey.AskOne(&label, &tok.Label, survey.WithStdio(cli.In(), cli.Out(), cli.Err()), survey.WithValidator(survey.Required))
+
+	tok, value, err := token.New(opts.label, opts.scopes, opts.expiresIn)
 	if err != nil {
 		return err
 	}
 
-	err = resource.Save[token.Token](token.Store, tok, func(t token.Token) string {
+	err = resource.Save[*token.Token](token.Store, tok, func(t *token.Token) string {
 		return t.Label
 	})
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(cli.Out(), "The token %s has been created.\n", tok.Value)
+	fmt.Fprintf(cli.Out(), "The token %s has been created with the scopes %s.\n", value, strings.Join(tok.Scopes, ", "))
+	fmt.Fprintln(cli.Out(), "Copy it now, it will not be shown again.")
 
 	return nil
 }
 
 func NewCreateCommand(cli *cli.CLI) *cobra.Command {
+	opts := createOptions{}
+
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "create a token",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCreateCommand(cli)
+			return runCreateCommand(cli, opts)
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.label, "label", "", "label identifying the token")
+	cmd.Flags().StringSliceVar(&opts.scopes, "scope", nil, "grant a scope to the token (available: "+strings.Join(token.Scopes, ", ")+"), defaults to "+strings.Join(token.DefaultScopes, ", "))
+	cmd.Flags().DurationVar(&opts.expiresIn, "expires-in", 0, "expire the token after the given duration (e.g. 720h), never expires by default")
+
 	return cmd
 }
@@ -17,7 +17,7 @@ func NewListCommand(cli *cli.CLI) *cobra.Command {
 		Short: "list tokens",
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return manager.ListCommand(cli)
+			return manager.ListCommand(cli, false)
 		},
 	}
 
@@ -8,47 +8,85 @@ import (
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
 )
 
-func runUseCommand(cli *cli.CLI) error {
+type useOptions struct {
+	latest bool
+}
+
+// runUseCommand sets the commit of the locator to the one the given ref points to.
+// If no ref is given, the user is prompted to pick a commit.
+func runUseCommand(cli *cli.CLI, ref string, opts useOptions) error {
 	l, err := locator.LoadFromStore()
 	if err != nil {
 		return err
 	}
 
-	commits, err := l.Commits()
-	if err != nil {
-		return err
+	if opts.latest {
+		if ref != "" {
+			return fmt.Errorf("--latest can not be used with a commit, a branch or a tag")
+		}
+
+		ref = locator.LatestRef
 	}
 
-	var response string
+	if ref == "" && !cli.IsInteractive() {
+		return fmt.Errorf("missing commit, run `vite use <commit|branch|tag>` or `vite use --latest` (prompts are disabled as stdin is not a terminal)")
+	}
+
+	if ref == "" {
+		commits, err := l.Commits()
+		if err != nil {
+			return err
+		}
+
+		var selected int
+
+		err = survey.AskOne(&survey.Select{
+			Message: "Select a commit",
+			Options: commits.AsOptions(),
+		}, &selected, survey.WithStdio(cli.In(), cli.Out(), cli.Err()))
+		if err != nil {
+			return err
+		}
 
-	err = survey.AskOne(&survey.Select{
-		Message: "Select a commit",
-		Options: commits.AsOptions(),
-	}, &response, survey.WithStdio(cli.In(), cli.Out(), cli.Err()))
+		ref = commits[selected].Hash
+	}
+
+	// the ref is resolved even when picked from the list to make sure the commit exists
+	commit, err := l.ResolveCommit(ref)
 	if err != nil {
 		return err
 	}
 
-	response = response[:40]
-
-	l.Commit = response
+	l.Commit = commit
 
 	err = l.Save()
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintln(cli.Out(), "Successfully set commit to", response)
+	fmt.Fprintln(cli.Out(), "Successfully set commit to", commit)
 
 	return nil
 }
+
 func NewUseCommand(cli *cli.CLI) *cobra.Command {
+	opts := useOptions{}
+
 	cmd := &cobra.Command{
-		Use:   "use",
+		Use:   "use [commit|branch|tag]",
 		Short: "set the current commit",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUseCommand(cli)
+			var ref string
+			if len(args) > 0 {
+				ref = args[0]
+			}
+
+			return runUseCommand(cli, ref, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.latest, "latest", false, "use the latest commit of the branch")
+
 	return cmd
 }
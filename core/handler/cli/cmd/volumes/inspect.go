@@ -0,0 +1,44 @@
+package volumes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runInspectCommand(cli *cli.CLI, name string) error {
+	docker, err := runtime.NewClient()
+	if err != nil {
+		return err
+	}
+
+	volume, err := docker.VolumeInspect(context.Background(), name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := volume.Labels[runtime.VolumeLabel]; !ok {
+		return fmt.Errorf("volume %s is not managed by vite", name)
+	}
+
+	encoder := json.NewEncoder(cli.Out())
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(volume)
+}
+
+func NewInspectCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [volume]",
+		Short: "show the details of a volume",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInspectCommand(cli, args[0])
+		},
+	}
+
+	return cmd
+}
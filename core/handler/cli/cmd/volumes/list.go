@@ -0,0 +1,42 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runListCommand(cli *cli.CLI) error {
+	docker, err := runtime.NewClient()
+	if err != nil {
+		return err
+	}
+
+	volumes, err := docker.VolumeList(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, volume := range volumes {
+		fmt.Fprintf(cli.Out(), "- %s | service %s | %s\n", volume.Name, volume.Labels["cloud.vite.service"], volume.Mountpoint)
+	}
+
+	fmt.Fprintf(cli.Out(), "\n%d found.\n", len(volumes))
+
+	return nil
+}
+
+func NewListCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list volumes managed by vite",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runListCommand(cli)
+		},
+	}
+
+	return cmd
+}
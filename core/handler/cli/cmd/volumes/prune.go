@@ -0,0 +1,51 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/domain/deployment"
+	"github.com/vite-cloud/vite/core/domain/runtime"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func runPruneCommand(cli *cli.CLI) error {
+	// A running deployment may be about to use a volume its config references.
+	lock, err := deployment.LockDeployments()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	docker, err := runtime.NewClient()
+	if err != nil {
+		return err
+	}
+
+	removed, err := deployment.PruneVolumes(context.Background(), docker)
+
+	for _, volume := range removed {
+		fmt.Fprintf(cli.Out(), "- removed %s\n", volume)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.Out(), "\n%d removed.\n", len(removed))
+
+	return nil
+}
+
+func NewPruneCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "remove volumes that are neither referenced by the current config nor used by a container",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPruneCommand(cli)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,20 @@
+package volumes
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vite-cloud/vite/core/handler/cli/cli"
+)
+
+func NewRootCommand(cli *cli.CLI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "volumes",
+		Short: "manage volumes holding the data of services",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(NewListCommand(cli))
+	cmd.AddCommand(NewInspectCommand(cli))
+	cmd.AddCommand(NewPruneCommand(cli))
+
+	return cmd
+}
@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"github.com/vite-cloud/vite/core/domain/config"
+	"github.com/vite-cloud/vite/core/domain/log"
+	configcmd "github.com/vite-cloud/vite/core/handler/cli/cmd/config"
 	"github.com/vite-cloud/vite/core/handler/cli/cmd/deployments"
 	"github.com/vite-cloud/vite/core/handler/cli/cmd/proxy"
+	"github.com/vite-cloud/vite/core/handler/cli/cmd/secrets"
+	"github.com/vite-cloud/vite/core/handler/cli/cmd/subnets"
 	"github.com/vite-cloud/vite/core/handler/cli/cmd/tokens"
+	"github.com/vite-cloud/vite/core/handler/cli/cmd/volumes"
 	"os"
 
 	"github.com/vite-cloud/vite/core/handler/cli/cli"
@@ -14,6 +20,8 @@ import (
 func New() *cli.CLI {
 	c := cli.New(os.Stdout, os.Stdin, os.Stderr)
 
+	log.SetRedactor(config.NewRedactor().String)
+
 	c.Add(
 		cmd.NewDiagnoseCommand(c),
 		cmd.NewSetupCommand(c),
@@ -27,6 +35,14 @@ func New() *cli.CLI {
 		deployments.NewDeploymentsCommand(c),
 
 		tokens.NewRootCommand(c),
+
+		secrets.NewRootCommand(c),
+
+		subnets.NewRootCommand(c),
+
+		volumes.NewRootCommand(c),
+
+		configcmd.NewRootCommand(c),
 	)
 
 	return c